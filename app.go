@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
+	"net/http"
 	"os"
 	"strings"
 
@@ -20,6 +23,10 @@ type App struct {
 	requestStorage     *RequestStorage
 	environmentStorage *EnvironmentStorage
 	tabStorage         *TabStorage
+	oauth2Tokens       *OAuth2TokenCache
+	responseBodies     *responseBodyCache
+	inFlight           *inFlightRequests
+	openAPIWatches     *openAPIWatches
 	activeEnvironment  string
 }
 
@@ -54,6 +61,11 @@ func NewApp() *App {
 		panic(fmt.Sprintf("Failed to initialize tab storage: %v", err))
 	}
 
+	oauth2Tokens, err := NewOAuth2TokenCache()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize oauth2 token cache: %v", err))
+	}
+
 	// Migration: specific project requests from history to request storage if empty
 	if len(requestStorage.requests) == 0 {
 		history := historyStorage.GetHistory(1000)
@@ -114,6 +126,10 @@ func NewApp() *App {
 		requestStorage:     requestStorage,
 		environmentStorage: environmentStorage,
 		tabStorage:         tabStorage,
+		oauth2Tokens:       oauth2Tokens,
+		responseBodies:     newResponseBodyCache(20),
+		inFlight:           newInFlightRequests(),
+		openAPIWatches:     newOpenAPIWatches(),
 		activeEnvironment:  environmentStorage.GetActiveEnvironmentID(),
 	}
 	
@@ -125,9 +141,36 @@ func (a *App) startup(ctx context.Context) {
 }
 
 func (a *App) SendRequest(req HttpRequest) (*HttpResponse, error) {
+	return a.sendRequest(a.ctx, req)
+}
+
+// sendRequest runs the scripts and the HTTP call under a context registered in
+// inFlight, so CancelRequest aborts whichever stage is currently running.
+func (a *App) sendRequest(parent context.Context, req HttpRequest) (*HttpResponse, error) {
 	processedReq := req
-	
-	scriptRunner := NewScriptRunner(a)
+
+	id := uuid.New().String()
+	ctx, done := a.inFlight.start(parent, id, req.ID)
+	defer done()
+
+	var project *Project
+	if processedReq.ProjectId != "" {
+		project = a.projectStorage.GetProject(processedReq.ProjectId)
+	}
+
+	if project != nil && project.Retry != nil && (processedReq.Settings == nil || processedReq.Settings.Retry == nil) {
+		settings := RequestSettings{}
+		if processedReq.Settings != nil {
+			settings = *processedReq.Settings
+		}
+		settings.Retry = project.Retry
+		processedReq.Settings = &settings
+	}
+
+	var authSource string
+	processedReq.Auth, authSource = resolveAuth(processedReq.Auth, project)
+
+	scriptRunner := NewScriptRunner(ctx, a)
 	
 	if processedReq.Scripts != nil && processedReq.Scripts.PreRequest != "" {
 		_, err := scriptRunner.RunPreRequestScript(&processedReq)
@@ -135,8 +178,13 @@ func (a *App) SendRequest(req HttpRequest) (*HttpResponse, error) {
 			fmt.Printf("Pre-request script error: %v\n", err)
 		}
 	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("请求已取消")
+	}
 	
 	processedReq.URL = a.ReplaceVariables(processedReq.URL)
+
+	applyTokens(&processedReq, a.tokenStorage.GetAllTokens(), a.GetActiveEnvironment())
 	
 	for i, header := range processedReq.Headers {
 		processedReq.Headers[i].Value = a.ReplaceVariables(header.Value)
@@ -145,13 +193,80 @@ func (a *App) SendRequest(req HttpRequest) (*HttpResponse, error) {
 	for i, param := range processedReq.Params {
 		processedReq.Params[i].Value = a.ReplaceVariables(param.Value)
 	}
+
+	for i, param := range processedReq.PathParams {
+		processedReq.PathParams[i].Value = a.ReplaceVariables(param.Value)
+	}
 	
+	// History keeps the request's own auth: inherit stays inherit, and
+	// placeholders are not replaced by the secrets.
+	savedAuth := req.Auth
+	if processedReq.Auth != nil {
+		auth := a.substituteAuthVariables(*processedReq.Auth)
+		processedReq.Auth = &auth
+	}
+
 	if processedReq.Body != nil {
 		processedReq.Body.Content = a.ReplaceVariables(processedReq.Body.Content)
+		processedReq.Body.FilePath = a.ReplaceVariables(processedReq.Body.FilePath)
 	}
-	
-	resp, err := a.httpClient.SendRequest(processedReq)
+
+	useTokenCache := processedReq.Auth != nil && processedReq.Auth.Type == AuthOAuth2 && processedReq.Auth.OAuth2TokenUrl != ""
+	if useTokenCache {
+		auth := *processedReq.Auth
+		if _, err := a.ensureOAuth2Token(&auth, false); err != nil {
+			fmt.Printf("OAuth2 token refresh failed: %v\n", err)
+		}
+		processedReq.Auth = &auth
+	}
+
+	send := func() (*HttpResponse, error) {
+		return a.httpClient.SendRequest(ctx, id, processedReq, func(received, total int64) {
+			a.emitEvent("request:progress", DownloadProgress{ID: id, RequestId: req.ID, Received: received, Total: total})
+		})
+	}
+
+	a.emitEvent("request:started", DownloadProgress{ID: id, RequestId: req.ID, Total: -1})
+	resp, err := send()
+	if err == nil && useTokenCache && resp.Status == http.StatusUnauthorized {
+		// The token may have been revoked or expired early: refresh it and retry once.
+		refreshed, refreshErr := a.ensureOAuth2Token(processedReq.Auth, true)
+		if refreshErr != nil {
+			fmt.Printf("OAuth2 token refresh failed: %v\n", refreshErr)
+		}
+		if refreshed {
+			earlier := resp.Attempts
+			removeResponseFile(*resp)
+			resp, err = send()
+
+			var retryErr *RetryError
+			if err == nil && len(earlier) > 0 {
+				resp.Attempts = continueAttempts(earlier, resp.Attempts)
+			} else if errors.As(err, &retryErr) {
+				retryErr.Attempts = continueAttempts(earlier, retryErr.Attempts)
+			}
+		}
+	}
+	a.emitEvent("request:finished", DownloadProgress{ID: id, RequestId: req.ID, Done: true})
+
+	historyReq := processedReq
+	historyReq.Auth = savedAuth
+
 	if err != nil {
+		// Requests that used up their retries are recorded with every attempt.
+		var retryErr *RetryError
+		if errors.As(err, &retryErr) {
+			a.addHistoryRecord(HistoryRecord{
+				ID:      id,
+				Request: historyReq,
+				Response: HttpResponse{
+					ID:         id,
+					Attempts:   retryErr.Attempts,
+					Error:      err.Error(),
+					AuthSource: authSource,
+				},
+			})
+		}
 		return nil, err
 	}
 
@@ -163,17 +278,96 @@ func (a *App) SendRequest(req HttpRequest) (*HttpResponse, error) {
 		resp.ScriptResult = scriptResult
 	}
 
-	record := HistoryRecord{
-		ID:       uuid.New().String(),
-		Request:  processedReq,
-		Response: *resp,
+	resp.ID = id
+	resp.AuthSource = authSource
+	if resp.rawBody != nil {
+		a.responseBodies.Put(resp.ID, resp.rawBody)
 	}
 
+	a.addHistoryRecord(HistoryRecord{
+		ID:       resp.ID,
+		Request:  historyReq,
+		Response: historyResponse(*resp),
+	})
+
+	return resp, nil
+}
+
+func (a *App) addHistoryRecord(record HistoryRecord) {
 	if err := a.historyStorage.AddRecord(record); err != nil {
 		fmt.Printf("Failed to save history: %v\n", err)
 	}
+}
 
-	return resp, nil
+// resolveAuth walks up from the request to its project until a level supplies
+// auth other than inherit, and reports which level that was.
+func resolveAuth(auth *Auth, project *Project) (*Auth, string) {
+	if auth == nil || auth.Type != AuthInherit {
+		if auth == nil || auth.Type == AuthNone {
+			return auth, ""
+		}
+		return auth, AuthSourceRequest
+	}
+	if project != nil && project.Auth != nil && project.Auth.Type != AuthInherit && project.Auth.Type != AuthNone {
+		return project.Auth, AuthSourceProject
+	}
+	return nil, ""
+}
+
+func (a *App) CancelRequest(id string) bool {
+	return a.inFlight.cancel(id)
+}
+
+func (a *App) emitEvent(name string, data interface{}) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, name, data)
+	}
+}
+
+func (a *App) SaveResponseBody(responseId string) (string, error) {
+	raw, ok := a.responseBodies.Get(responseId)
+	record := a.historyStorage.GetRecord(responseId)
+	if !ok && record == nil {
+		return "", fmt.Errorf("response not found: %s", responseId)
+	}
+
+	defaultFilename := "response"
+	if record != nil {
+		if exts, _ := mime.ExtensionsByType(record.Response.ContentType); len(exts) > 0 {
+			defaultFilename += exts[0]
+		}
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: defaultFilename,
+		Title:           "Save Response",
+	})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	if !ok && record.Response.BodyFile != "" {
+		if err := copyFile(record.Response.BodyFile, path); err != nil {
+			return "", fmt.Errorf("failed to write response file: %w", err)
+		}
+		return path, nil
+	}
+
+	if !ok {
+		raw, err = rawResponseBody(record.Response)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		return "", fmt.Errorf("failed to write response file: %w", err)
+	}
+
+	return path, nil
 }
 
 func (a *App) GetHistory(limit int) []HistoryRecord {
@@ -255,13 +449,34 @@ func (a *App) ImportOpenAPI(fileContent string, format string, projectId string,
 		return nil, fmt.Errorf("failed to parse OpenAPI file: %w", err)
 	}
 
-	requests := ConvertOpenAPIToRequests(spec, projectId, baseURL)
-	
-	if err := a.requestStorage.AddRequests(requests); err != nil {
-		return nil, fmt.Errorf("failed to save requests: %w", err)
+	// Re-imports update the requests of earlier imports in place.
+	_, requests, err := a.syncOpenAPISpec(spec, projectId, OpenAPISyncOptions{})
+	return requests, err
+}
+
+// ImportOpenAPIFile imports a spec from disk so that $refs to other files can
+// be resolved. filePath may also be a directory or zip archive of a spec split
+// across several files. An empty filePath asks the user to pick a file.
+func (a *App) ImportOpenAPIFile(filePath string, projectId string, baseURL string) ([]HttpRequest, error) {
+	if filePath == "" {
+		path, err := a.SelectOpenAPIFile()
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			return nil, nil
+		}
+		filePath = path
+	}
+
+	spec, err := ParseOpenAPIPath(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI file: %w", err)
 	}
 
-	return requests, nil
+	// Re-imports update the requests of earlier imports in place.
+	_, requests, err := a.syncOpenAPISpec(spec, projectId, OpenAPISyncOptions{})
+	return requests, err
 }
 
 func (a *App) ExportProjectAPI(projectId string) error {
@@ -357,6 +572,40 @@ func (a *App) ReplaceVariables(text string) string {
 	return result
 }
 
+// substituteAuthVariables returns a copy of auth with {{variables}} replaced
+// in its credentials and endpoints. The saved auth keeps the placeholders.
+func (a *App) substituteAuthVariables(auth Auth) Auth {
+	for _, field := range []*string{
+		&auth.Username, &auth.Password, &auth.Token, &auth.Domain,
+		&auth.OAuth2AuthUrl, &auth.OAuth2TokenUrl, &auth.OAuth2DeviceAuthUrl, &auth.OAuth2Issuer,
+		&auth.OAuth2ClientId, &auth.OAuth2ClientSecret, &auth.OAuth2Scope, &auth.OAuth2RedirectUrl,
+		&auth.OAuth2ClientPrivateKey, &auth.OAuth2ClientKeyId,
+		&auth.OAuth2Username, &auth.OAuth2SubjectToken, &auth.OAuth2ActorToken, &auth.OAuth2Audience, &auth.OAuth2Resource, &auth.OAuth2Assertion,
+		&auth.AwsAccessKey, &auth.AwsSecretKey, &auth.AwsSessionToken, &auth.AwsRegion, &auth.AwsService,
+		&auth.HawkId, &auth.HawkKey, &auth.HawkExt,
+		&auth.ApiKeyName, &auth.ApiKeyValue,
+		&auth.JwtSecret, &auth.JwtPrivateKey, &auth.JwtHeader, &auth.JwtPayload,
+	} {
+		*field = a.ReplaceVariables(*field)
+	}
+
+	auth.OAuth2ExtraParams = a.substituteKeyValues(auth.OAuth2ExtraParams)
+	auth.OAuth2ExtraHeaders = a.substituteKeyValues(auth.OAuth2ExtraHeaders)
+	return auth
+}
+
+func (a *App) substituteKeyValues(kvs []KeyValue) []KeyValue {
+	if kvs == nil {
+		return nil
+	}
+	result := make([]KeyValue, len(kvs))
+	for i, kv := range kvs {
+		kv.Value = a.ReplaceVariables(kv.Value)
+		result[i] = kv
+	}
+	return result
+}
+
 func (a *App) GetSavedTabs() []TabState {
 	return a.tabStorage.GetAllTabs()
 }
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// digestChallenge holds the parameters of a WWW-Authenticate: Digest header.
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+func parseDigestChallenge(headers []KeyValue) *digestChallenge {
+	for _, header := range headers {
+		if !strings.EqualFold(header.Key, "WWW-Authenticate") {
+			continue
+		}
+		value := strings.TrimSpace(header.Value)
+		if len(value) < 7 || !strings.EqualFold(value[:7], "Digest ") {
+			continue
+		}
+		params := parseAuthParams(value[7:])
+		challenge := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+		}
+		for _, qop := range strings.Split(params["qop"], ",") {
+			if strings.TrimSpace(qop) == "auth" {
+				challenge.qop = "auth"
+			}
+		}
+		return challenge
+	}
+	return nil
+}
+
+// parseAuthParams splits a comma separated list of key=value or key="value" pairs.
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " ")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var sb strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				sb.WriteByte(s[i])
+			}
+			value = sb.String()
+			s = s[min(i+1, len(s)):]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+	return params
+}
+
+func digestAuthorization(httpReq *http.Request, auth *Auth, challenge *digestChallenge) string {
+	algorithm := strings.ToUpper(challenge.algorithm)
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	newHash := md5.New
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+	digest := func(s string) string {
+		h := newHash()
+		h.Write([]byte(s))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	uri := httpReq.URL.RequestURI()
+	cnonce := randomHex(16)
+	nc := "00000001"
+
+	ha1 := digest(auth.Username + ":" + challenge.realm + ":" + auth.Password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = digest(ha1 + ":" + challenge.nonce + ":" + cnonce)
+	}
+	ha2 := digest(httpReq.Method + ":" + uri)
+
+	var response string
+	if challenge.qop != "" {
+		response = digest(strings.Join([]string{ha1, challenge.nonce, nc, cnonce, challenge.qop, ha2}, ":"))
+	} else {
+		response = digest(ha1 + ":" + challenge.nonce + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, escapeQuotes(auth.Username)),
+		fmt.Sprintf(`realm="%s"`, escapeQuotes(challenge.realm)),
+		fmt.Sprintf(`nonce="%s"`, escapeQuotes(challenge.nonce)),
+		fmt.Sprintf(`uri="%s"`, escapeQuotes(uri)),
+		"algorithm=" + algorithm,
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if challenge.qop != "" {
+		parts = append(parts, "qop="+challenge.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if challenge.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, escapeQuotes(challenge.opaque)))
+	}
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+// hashPayload returns the hex SHA-256 of the body for AWS Signature V4 and the
+// body's length, or -1 when unknown. In-memory bodies are read and replaced,
+// files are hashed and rewound, and streams such as multipart or compressed
+// bodies are spooled to a temporary file that is removed once it is closed.
+func hashPayload(body io.Reader, inMemory bool) (io.Reader, string, int64, error) {
+	if body == nil {
+		return nil, hex.EncodeToString(sha256.New().Sum(nil)), -1, nil
+	}
+
+	if file, ok := body.(*os.File); ok {
+		h := sha256.New()
+		n, err := io.Copy(h, file)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, "", 0, err
+		}
+		return file, hex.EncodeToString(h.Sum(nil)), n, nil
+	}
+
+	if inMemory {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		sum := sha256.Sum256(data)
+		return bytes.NewReader(data), hex.EncodeToString(sum[:]), int64(len(data)), nil
+	}
+
+	file, err := os.CreateTemp("", "postgo-upload-*")
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("无法创建临时文件: %w", err)
+	}
+	spooled := &spooledBody{File: file}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(file, h), body)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		return nil, "", 0, err
+	}
+	return spooled, hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// spooledBody is a request body written to a temporary file so it could be
+// hashed before sending; closing it removes the file.
+type spooledBody struct {
+	*os.File
+}
+
+func (b *spooledBody) Close() error {
+	err := b.File.Close()
+	os.Remove(b.File.Name())
+	return err
+}
+
+// signAWSV4 adds an AWS Signature Version 4 Authorization header. It must run
+// after every other header has been set because the signature covers them.
+func signAWSV4(httpReq *http.Request, auth *Auth, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	region := auth.AwsRegion
+	if region == "" {
+		region = "us-east-1"
+	}
+	service := auth.AwsService
+	if service == "" {
+		service = "s3"
+	}
+
+	httpReq.Header.Set("X-Amz-Date", amzDate)
+	httpReq.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if auth.AwsSessionToken != "" {
+		httpReq.Header.Set("X-Amz-Security-Token", auth.AwsSessionToken)
+	}
+
+	host := httpReq.Host
+	if host == "" {
+		host = httpReq.URL.Host
+	}
+
+	signed := map[string]string{"host": host}
+	for name, values := range httpReq.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			signed[lower] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		httpReq.Method,
+		awsCanonicalURI(httpReq.URL, service),
+		awsCanonicalQuery(httpReq.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+auth.AwsSecretKey), dateStamp)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	httpReq.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		auth.AwsAccessKey, scope, signedHeaders, signature))
+}
+
+// awsCanonicalURI encodes each path segment; S3 is the one service whose
+// paths are not encoded a second time.
+func awsCanonicalURI(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			decoded = segment
+		}
+		encoded := awsURIEncode(decoded)
+		if service != "s3" {
+			encoded = awsURIEncode(encoded)
+		}
+		segments[i] = encoded
+	}
+	return strings.Join(segments, "/")
+}
+
+func awsCanonicalQuery(u *url.URL) string {
+	// Pairs are sorted by their encoded key, then encoded value, since the
+	// encoding can change the order of raw keys such as "a b" and "a-b".
+	var pairs [][2]string
+	for key, values := range u.Query() {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{awsURIEncode(key), awsURIEncode(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	encoded := make([]string, len(pairs))
+	for i, pair := range pairs {
+		encoded[i] = pair[0] + "=" + pair[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+func awsURIEncode(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// hawkAuthorization builds a Hawk header (without payload validation) for the request.
+func hawkAuthorization(httpReq *http.Request, auth *Auth, now time.Time) (string, error) {
+	var newHash func() hash.Hash
+	switch strings.ToLower(auth.HawkAlgorithm) {
+	case "", "sha256":
+		newHash = sha256.New
+	case "sha1":
+		newHash = sha1.New
+	default:
+		return "", fmt.Errorf("不支持的 Hawk 算法: %s", auth.HawkAlgorithm)
+	}
+
+	host := httpReq.Host
+	if host == "" {
+		host = httpReq.URL.Host
+	}
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = host
+		port = "80"
+		if httpReq.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	ts := strconv.FormatInt(now.Unix(), 10)
+	nonce := randomHex(6)
+	normalized := strings.Join([]string{
+		"hawk.1.header",
+		ts,
+		nonce,
+		strings.ToUpper(httpReq.Method),
+		httpReq.URL.RequestURI(),
+		strings.ToLower(hostname),
+		port,
+		"",
+		auth.HawkExt,
+	}, "\n") + "\n"
+
+	mac := hmac.New(newHash, []byte(auth.HawkKey))
+	mac.Write([]byte(normalized))
+
+	header := fmt.Sprintf(`Hawk id="%s", ts="%s", nonce="%s", mac="%s"`,
+		escapeQuotes(auth.HawkId), ts, nonce, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	if auth.HawkExt != "" {
+		header += fmt.Sprintf(`, ext="%s"`, escapeQuotes(auth.HawkExt))
+	}
+	return header, nil
+}
+
+// applyAPIKey places the key in a header, the query string or a cookie.
+func applyAPIKey(httpReq *http.Request, auth *Auth) {
+	if auth.ApiKeyName == "" {
+		return
+	}
+	switch strings.ToLower(auth.ApiKeyIn) {
+	case "query":
+		pair := url.QueryEscape(auth.ApiKeyName) + "=" + url.QueryEscape(auth.ApiKeyValue)
+		if httpReq.URL.RawQuery == "" {
+			httpReq.URL.RawQuery = pair
+		} else {
+			httpReq.URL.RawQuery += "&" + pair
+		}
+	case "cookie":
+		httpReq.AddCookie(&http.Cookie{Name: auth.ApiKeyName, Value: auth.ApiKeyValue})
+	default:
+		httpReq.Header.Set(auth.ApiKeyName, auth.ApiKeyValue)
+	}
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type CollectionRunResult struct {
+	ID           string                  `json:"id"`
 	ProjectId    string                  `json:"projectId"`
 	ProjectName  string                  `json:"projectName"`
 	StartTime    time.Time               `json:"startTime"`
@@ -14,6 +19,7 @@ type CollectionRunResult struct {
 	TotalTests   int                     `json:"totalTests"`
 	PassedTests  int                     `json:"passedTests"`
 	FailedTests  int                     `json:"failedTests"`
+	Cancelled    bool                    `json:"cancelled"`
 	RequestResults []RequestRunResult    `json:"requestResults"`
 }
 
@@ -30,6 +36,7 @@ type RequestRunResult struct {
 	Tests        []TestResult  `json:"tests,omitempty"`
 	PassedTests  int           `json:"passedTests"`
 	FailedTests  int           `json:"failedTests"`
+	Attempts     []RetryAttempt `json:"attempts,omitempty"`
 }
 
 func (a *App) RunCollection(projectId string) (*CollectionRunResult, error) {
@@ -44,14 +51,25 @@ func (a *App) RunCollection(projectId string) (*CollectionRunResult, error) {
 	}
 
 	result := &CollectionRunResult{
+		ID:             uuid.New().String(),
 		ProjectId:      projectId,
 		ProjectName:    project.Name,
 		StartTime:      time.Now(),
 		RequestResults: []RequestRunResult{},
 	}
 
+	// The run is registered under the project ID so CancelRequest(projectId)
+	// stops the request in flight and skips the rest of the collection.
+	ctx, done := a.inFlight.start(a.ctx, result.ID, projectId)
+	defer done()
+	a.emitEvent("collection:started", map[string]string{"id": result.ID, "projectId": projectId})
+
 	for _, req := range requests {
-		reqResult := a.runSingleRequest(req)
+		if ctx.Err() != nil {
+			break
+		}
+
+		reqResult := a.runSingleRequest(ctx, req)
 		result.RequestResults = append(result.RequestResults, reqResult)
 		
 		result.TotalTests += len(reqResult.Tests)
@@ -59,13 +77,17 @@ func (a *App) RunCollection(projectId string) (*CollectionRunResult, error) {
 		result.FailedTests += reqResult.FailedTests
 	}
 
+	if ctx.Err() != nil {
+		result.Cancelled = true
+	}
+
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime).Milliseconds()
 
 	return result, nil
 }
 
-func (a *App) runSingleRequest(req HttpRequest) RequestRunResult {
+func (a *App) runSingleRequest(ctx context.Context, req HttpRequest) RequestRunResult {
 	startTime := time.Now()
 	
 	reqResult := RequestRunResult{
@@ -77,16 +99,21 @@ func (a *App) runSingleRequest(req HttpRequest) RequestRunResult {
 		Tests:       []TestResult{},
 	}
 
-	resp, err := a.SendRequest(req)
+	resp, err := a.sendRequest(ctx, req)
 	duration := time.Since(startTime).Milliseconds()
 	reqResult.Duration = duration
 
 	if err != nil {
+		var retryErr *RetryError
+		if errors.As(err, &retryErr) {
+			reqResult.Attempts = retryErr.Attempts
+		}
 		reqResult.Error = err.Error()
 		reqResult.Success = false
 		return reqResult
 	}
 
+	reqResult.Attempts = resp.Attempts
 	reqResult.Status = resp.Status
 	reqResult.StatusText = resp.StatusText
 	reqResult.Success = resp.Status >= 200 && resp.Status < 300
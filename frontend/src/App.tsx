@@ -1,8 +1,9 @@
-import { useState, useEffect } from 'react';
+import { useState, useEffect, useRef } from 'react';
 import { History, Key, Info, Globe, Download, Upload } from 'lucide-react';
 import './App.css';
 import { 
   SendRequest, 
+  CancelRequest,
   GetHistory, 
   ClearHistory, 
   DeleteHistoryRecord,
@@ -15,6 +16,12 @@ import {
   DeleteToken,
   GetProjectRequests,
   ImportOpenAPI,
+  SyncOpenAPI,
+  SyncOpenAPIURL,
+  SyncOpenAPIFile,
+  ImportOpenAPIFromURL,
+  ImportOpenAPIDirectory,
+  ImportOpenAPIFile,
   SaveRequest,
   GetAllEnvironments,
   SaveEnvironment,
@@ -26,16 +33,41 @@ import {
   ExportAllData,
   ImportAllData
 } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
 import TabBar from './components/TabBar';
 import RequestEditor from './components/RequestEditor';
 import ResponseViewer from './components/ResponseViewer';
 import HistoryPanel from './components/HistoryPanel';
 import ProjectSidebar from './components/ProjectSidebar';
-import { Tab, HttpRequest, HttpResponse, HistoryRecord, Project, Token, Environment } from './types';
+import { Tab, HttpRequest, HttpResponse, HistoryRecord, Project, Token, Environment, OpenAPISource } from './types';
 import { main } from '../wailsjs/go/models';
 import TokenManager from './components/TokenManager';
 import AboutDialog from './components/AboutDialog';
 import EnvironmentManager from './components/EnvironmentManager';
+import OpenAPISyncDialog from './components/OpenAPISyncDialog';
+
+interface PendingOpenAPISync {
+  diff: main.OpenAPISyncDiff;
+  resolve: (options: { removeMissing: boolean; adoptMatching: boolean } | null) => void;
+}
+
+interface DownloadProgress {
+  id: string;
+  requestId?: string;
+  received: number;
+  total: number;
+  done: boolean;
+}
+
+interface OpenAPIWatchEvent {
+  projectId: string;
+  source: string;
+  diff?: main.OpenAPISyncDiff;
+  applied: boolean;
+  error?: string;
+}
+
+const emptySyncDiff = new main.OpenAPISyncDiff({ added: [], removed: [], changed: [], adopted: [], unchanged: 0 });
 
 function App() {
   const [tabs, setTabs] = useState<Tab[]>([]);
@@ -54,6 +86,10 @@ function App() {
   const [environments, setEnvironments] = useState<Environment[]>([]);
   const [activeEnvironmentId, setActiveEnvironmentId] = useState<string>('');
   const [showEnvironmentManager, setShowEnvironmentManager] = useState(false);
+  const [pendingSync, setPendingSync] = useState<PendingOpenAPISync | null>(null);
+  const [openAPIEvent, setOpenAPIEvent] = useState<OpenAPIWatchEvent | null>(null);
+  const [progress, setProgress] = useState<DownloadProgress | null>(null);
+  const sendingRequestId = useRef('');
 
   useEffect(() => {
     const initializeApp = async () => {
@@ -90,6 +126,25 @@ function App() {
     initializeApp();
   }, []);
 
+  useEffect(() => {
+    // Download progress of the request being sent from the active tab.
+    return EventsOn('request:progress', (event: DownloadProgress) => {
+      if (event.requestId === sendingRequestId.current) {
+        setProgress(event);
+      }
+    });
+  }, []);
+
+  useEffect(() => {
+    // Watched specs report what each change did to the project.
+    return EventsOn('openapi:changed', (event: OpenAPIWatchEvent) => {
+      if (event.applied) {
+        loadHistory();
+      }
+      setOpenAPIEvent(event);
+    });
+  }, []);
+
   const loadEnvironments = async () => {
     try {
       const envsData = await GetAllEnvironments();
@@ -298,30 +353,91 @@ function App() {
     }
   };
 
+  // syncWithPreview shows what importing into a project that already holds
+  // requests from this spec would change, and applies it once confirmed. It
+  // reports false when there is nothing to update and a plain import is due.
+  const syncWithPreview = async (projectId: string, sync: (options: main.OpenAPISyncOptions) => Promise<main.OpenAPISyncDiff>) => {
+    if (!projectId) return false;
+
+    const diff = await sync(new main.OpenAPISyncOptions({ removeMissing: false, dryRun: true }));
+    if (diff.changed.length === 0 && diff.removed.length === 0 && diff.adopted.length === 0 && diff.unchanged === 0) {
+      return false;
+    }
+
+    const choice = await new Promise<{ removeMissing: boolean; adoptMatching: boolean } | null>((resolve) => {
+      setPendingSync({ diff, resolve });
+    });
+    setPendingSync(null);
+    if (choice === null) return true;
+
+    const applied = await sync(new main.OpenAPISyncOptions({ ...choice, dryRun: false }));
+    await loadHistory();
+    setSelectedProjectId(projectId);
+    const adopted = choice.adoptMatching ? applied.adopted.length : 0;
+    const added = applied.added.length + applied.adopted.length - adopted;
+    alert(`同步完成: 新增 ${added} 个, 更新 ${applied.changed.length + adopted} 个 API`);
+    return true;
+  };
+
+  const openImportedRequests = async (requests: HttpRequest[], projectId: string) => {
+    console.log(`Imported ${requests.length} requests`);
+    
+    await loadHistory();
+    
+    if (requests.length > 0) {
+      const firstRequest = requests[0];
+      const newRequest = new main.HttpRequest(firstRequest);
+      const newTab: Tab = {
+        id: `tab-${Date.now()}`,
+        title: firstRequest.name || firstRequest.url,
+        request: newRequest,
+      };
+      setTabs([...tabs, newTab]);
+      setActiveTabId(newTab.id);
+      
+      if (projectId) {
+        setSelectedProjectId(projectId);
+      }
+    }
+    
+    alert(`成功导入 ${requests.length} 个 API`);
+  };
+
   const handleImport = async (fileContent: string, format: string, projectId: string, baseURL: string) => {
     try {
+      if (await syncWithPreview(projectId, (options) => SyncOpenAPI(fileContent, format, projectId, options))) {
+        return;
+      }
+
       const requests = await ImportOpenAPI(fileContent, format, projectId, baseURL);
-      console.log(`Imported ${requests.length} requests`);
-      
-      await loadHistory();
-      
-      if (requests.length > 0) {
-        const firstRequest = requests[0];
-        const newRequest = new main.HttpRequest(firstRequest);
-        const newTab: Tab = {
-          id: `tab-${Date.now()}`,
-          title: firstRequest.name || firstRequest.url,
-          request: newRequest,
-        };
-        setTabs([...tabs, newTab]);
-        setActiveTabId(newTab.id);
-        
-        if (projectId) {
-          setSelectedProjectId(projectId);
-        }
+      await openImportedRequests(requests || [], projectId);
+    } catch (err) {
+      console.error('Failed to import:', err);
+      alert('导入失败: ' + err);
+    }
+  };
+
+  const handleImportSource = async (source: OpenAPISource, projectId: string) => {
+    try {
+      const sync = (options: main.OpenAPISyncOptions) => source.kind === 'url'
+        ? SyncOpenAPIURL(source.url, source.headers, projectId, options)
+        : SyncOpenAPIFile(source.path, projectId, options);
+      if (await syncWithPreview(projectId, sync)) {
+        return;
       }
-      
-      alert(`成功导入 ${requests.length} 个 API`);
+
+      let requests: HttpRequest[];
+      switch (source.kind) {
+        case 'url':
+          requests = await ImportOpenAPIFromURL(source.url, source.headers, projectId);
+          break;
+        case 'directory':
+          requests = await ImportOpenAPIDirectory(source.path, projectId);
+          break;
+        default:
+          requests = await ImportOpenAPIFile(source.path, projectId, '');
+      }
+      await openImportedRequests(requests || [], projectId);
     } catch (err) {
       console.error('Failed to import:', err);
       alert('导入失败: ' + err);
@@ -409,6 +525,8 @@ function App() {
     if (!activeTab) return;
 
     setLoading(true);
+    setProgress(null);
+    sendingRequestId.current = activeTab.request.id;
     
     setTabs(tabs.map(tab =>
       tab.id === activeTabId
@@ -442,10 +560,18 @@ function App() {
           : tab
       ));
     } finally {
+      sendingRequestId.current = '';
+      setProgress(null);
       setLoading(false);
     }
   };
 
+  const handleCancel = () => {
+    if (sendingRequestId.current) {
+      CancelRequest(sendingRequestId.current);
+    }
+  };
+
   const handleSaveRequest = async () => {
     const activeTab = tabs.find(tab => tab.id === activeTabId);
     if (!activeTab || !activeTab.request.projectId) return;
@@ -623,6 +749,7 @@ function App() {
             onToggleCollapse={() => setSidebarCollapsed(!sidebarCollapsed)}
             onOpenRequest={handleSelectHistory}
             onImport={handleImport}
+            onImportSource={handleImportSource}
           />
         </div>
         
@@ -633,6 +760,8 @@ function App() {
                 request={activeTab.request}
                 onRequestChange={handleRequestChange}
                 onSend={handleSend}
+                onCancel={handleCancel}
+                loading={loading}
                 onSave={handleSaveRequest}
                 tokens={tokens}
               />
@@ -653,6 +782,8 @@ function App() {
               <ResponseViewer
                 response={activeTab.response}
                 loading={loading}
+                progress={progress}
+                onCancel={handleCancel}
                 error={activeTab.error}
               />
             )}
@@ -685,6 +816,25 @@ function App() {
         <AboutDialog onClose={() => setShowAboutDialog(false)} />
       )}
 
+      {pendingSync && (
+        <OpenAPISyncDialog
+          title="同步 OpenAPI"
+          diff={pendingSync.diff}
+          onApply={(removeMissing, adoptMatching) => pendingSync.resolve({ removeMissing, adoptMatching })}
+          onClose={() => pendingSync.resolve(null)}
+        />
+      )}
+
+      {openAPIEvent && (
+        <OpenAPISyncDialog
+          title={`OpenAPI 已更新: ${openAPIEvent.source}`}
+          diff={openAPIEvent.diff || emptySyncDiff}
+          applied={openAPIEvent.applied}
+          error={openAPIEvent.error}
+          onClose={() => setOpenAPIEvent(null)}
+        />
+      )}
+
       {showEnvironmentManager && (
         <EnvironmentManager
           environments={environments}
@@ -110,7 +110,7 @@ export default function HistoryPanel({
                       {record.request.method}
                     </span>
                     <span className={`text-sm font-medium ${getStatusColor(record.response.status)}`}>
-                      {record.response.status}
+                      {record.response.error ? '失败' : record.response.status}
                     </span>
                   </div>
                   <button
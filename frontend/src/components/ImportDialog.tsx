@@ -1,15 +1,24 @@
 import { useState, useEffect } from 'react';
-import { X, Upload, FileJson, FileCode } from 'lucide-react';
-import { Project } from '../types';
+import { X, Upload, FileJson, FileCode, Link, FolderOpen, Plus, Trash2 } from 'lucide-react';
+import { Project, KeyValue, OpenAPISource } from '../types';
+import { main } from '../../wailsjs/go/models';
+import { SelectOpenAPIDirectory, SelectOpenAPIFile } from '../../wailsjs/go/main/App';
 
 interface ImportDialogProps {
   projects: Project[];
   onImport: (fileContent: string, format: string, projectId: string, baseURL: string) => void;
+  onImportSource: (source: OpenAPISource, projectId: string) => void;
   onClose: () => void;
   preselectedProjectId?: string;
 }
 
-export default function ImportDialog({ projects, onImport, onClose, preselectedProjectId = '' }: ImportDialogProps) {
+type ImportMode = 'upload' | 'url' | 'path';
+
+export default function ImportDialog({ projects, onImport, onImportSource, onClose, preselectedProjectId = '' }: ImportDialogProps) {
+  const [mode, setMode] = useState<ImportMode>('upload');
+  const [specURL, setSpecURL] = useState('');
+  const [headers, setHeaders] = useState<KeyValue[]>([]);
+  const [pickedSource, setPickedSource] = useState<OpenAPISource | null>(null);
   const [fileContent, setFileContent] = useState('');
   const [format, setFormat] = useState<'json' | 'yaml'>('json');
   const [projectId, setProjectId] = useState(preselectedProjectId);
@@ -53,7 +62,43 @@ export default function ImportDialog({ projects, onImport, onClose, preselectedP
     reader.readAsText(file);
   };
 
+  const updateHeader = (index: number, field: 'key' | 'value', value: string) => {
+    const updated = [...headers];
+    updated[index] = new main.KeyValue({ ...updated[index], [field]: value });
+    setHeaders(updated);
+  };
+
+  const handlePick = async (kind: 'directory' | 'file') => {
+    try {
+      const path = kind === 'directory' ? await SelectOpenAPIDirectory() : await SelectOpenAPIFile();
+      if (path) {
+        setPickedSource({ kind, path });
+      }
+    } catch (err) {
+      console.error('Failed to select OpenAPI source:', err);
+    }
+  };
+
+  // An empty URL, or a path such as /openapi.json, is resolved against the
+  // project's base URL by the backend.
+  const canImport = mode === 'upload'
+    ? !!fileContent
+    : mode === 'url'
+      ? !!specURL.trim() || !!projectId
+      : !!pickedSource;
+
   const handleImport = () => {
+    if (mode === 'url') {
+      onImportSource({ kind: 'url', url: specURL.trim(), headers: headers.filter(h => h.key) }, projectId);
+      return;
+    }
+    if (mode === 'path') {
+      if (pickedSource) {
+        onImportSource(pickedSource, projectId);
+      }
+      return;
+    }
+
     if (!fileContent) {
       alert('Please select a file first');
       return;
@@ -62,13 +107,25 @@ export default function ImportDialog({ projects, onImport, onClose, preselectedP
     onImport(fileContent, format, projectId, baseURL);
   };
 
+  const modeButton = (value: ImportMode, label: string, Icon: typeof Upload) => (
+    <button
+      onClick={() => setMode(value)}
+      className={`flex-1 py-2 rounded flex items-center justify-center gap-2 text-sm ${
+        mode === value ? 'bg-blue-600 text-white' : 'bg-gray-700 hover:bg-gray-600 text-gray-300'
+      }`}
+    >
+      <Icon size={16} />
+      {label}
+    </button>
+  );
+
   return (
     <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50">
       <div className="bg-gray-800 rounded-lg p-6 w-[600px]">
         <div className="flex items-center justify-between mb-6">
           <h2 className="text-xl font-semibold text-white flex items-center gap-2">
             <Upload size={24} />
-            导入 OpenAPI 文件
+            导入 OpenAPI
           </h2>
           <button
             onClick={onClose}
@@ -78,79 +135,172 @@ export default function ImportDialog({ projects, onImport, onClose, preselectedP
           </button>
         </div>
 
+        <div className="flex gap-2 mb-4">
+          {modeButton('upload', '上传文件', Upload)}
+          {modeButton('url', 'URL', Link)}
+          {modeButton('path', '目录 / ZIP', FolderOpen)}
+        </div>
+
         <div className="space-y-4">
-          <div>
-            <label className="block text-sm text-gray-300 mb-2">
-              选择文件
-            </label>
-            <div className="flex items-center gap-3">
-              <label className="flex-1 cursor-pointer">
-                <div className="border-2 border-dashed border-gray-600 rounded-lg p-6 hover:border-blue-500 transition-colors">
-                  <div className="flex flex-col items-center gap-2">
-                    {fileName ? (
-                      <>
-                        {format === 'json' ? (
-                          <FileJson size={32} className="text-blue-500" />
-                        ) : (
-                          <FileCode size={32} className="text-green-500" />
-                        )}
-                        <p className="text-white text-sm">{fileName}</p>
-                        <p className="text-gray-500 text-xs">
-                          {format.toUpperCase()} 格式
-                        </p>
-                      </>
-                    ) : (
-                      <>
-                        <Upload size={32} className="text-gray-500" />
-                        <p className="text-gray-400 text-sm">
-                          点击选择文件或拖拽到此处
-                        </p>
-                        <p className="text-gray-500 text-xs">
-                          支持 .json, .yml, .yaml 文件
-                        </p>
-                      </>
-                    )}
-                  </div>
-                </div>
-                <input
-                  type="file"
-                  accept=".json,.yml,.yaml"
-                  onChange={handleFileSelect}
-                  className="hidden"
-                />
+          {mode === 'url' && (
+            <div>
+              <label className="block text-sm text-gray-300 mb-1">
+                规范 URL
               </label>
+              <input
+                type="text"
+                value={specURL}
+                onChange={(e) => setSpecURL(e.target.value)}
+                placeholder="例如: https://api.example.com/openapi.json 或 /openapi.json"
+                className="w-full px-3 py-2 bg-gray-700 border border-gray-600 rounded text-white placeholder-gray-400"
+              />
+              <p className="text-gray-500 text-xs mt-1">
+                留空或只填路径时使用项目的 Base URL，支持 {'{{变量}}'}
+              </p>
+
+              <div className="flex items-center justify-between mt-3 mb-1">
+                <label className="text-sm text-gray-300">请求头（仅发送到规范所在主机）</label>
+                <button
+                  onClick={() => setHeaders([...headers, new main.KeyValue({ key: '', value: '', enabled: true })])}
+                  className="text-blue-400 hover:text-blue-300 flex items-center gap-1 text-sm"
+                >
+                  <Plus size={14} />
+                  添加
+                </button>
+              </div>
+              {headers.map((header, index) => (
+                <div key={index} className="flex gap-2 mb-2">
+                  <input
+                    type="text"
+                    value={header.key}
+                    onChange={(e) => updateHeader(index, 'key', e.target.value)}
+                    placeholder="Authorization"
+                    className="flex-1 px-3 py-2 bg-gray-700 border border-gray-600 rounded text-white placeholder-gray-400"
+                  />
+                  <input
+                    type="text"
+                    value={header.value}
+                    onChange={(e) => updateHeader(index, 'value', e.target.value)}
+                    placeholder="Bearer {{token}}"
+                    className="flex-1 px-3 py-2 bg-gray-700 border border-gray-600 rounded text-white placeholder-gray-400"
+                  />
+                  <button
+                    onClick={() => setHeaders(headers.filter((_, i) => i !== index))}
+                    className="text-gray-400 hover:text-red-400"
+                  >
+                    <Trash2 size={16} />
+                  </button>
+                </div>
+              ))}
             </div>
-          </div>
-
-          <div>
-            <label className="block text-sm text-gray-300 mb-1">
-              文件格式
-            </label>
-            <div className="flex gap-4">
-              <label className="flex items-center gap-2 cursor-pointer">
-                <input
-                  type="radio"
-                  name="format"
-                  value="json"
-                  checked={format === 'json'}
-                  onChange={(e) => setFormat(e.target.value as 'json')}
-                  className="text-blue-600"
-                />
-                <span className="text-white">JSON</span>
+          )}
+
+          {mode === 'path' && (
+            <div>
+              <label className="block text-sm text-gray-300 mb-2">
+                选择拆分为多个文件的规范
               </label>
-              <label className="flex items-center gap-2 cursor-pointer">
-                <input
-                  type="radio"
-                  name="format"
-                  value="yaml"
-                  checked={format === 'yaml'}
-                  onChange={(e) => setFormat(e.target.value as 'yaml')}
-                  className="text-blue-600"
-                />
-                <span className="text-white">YAML</span>
+              <div className="flex gap-3">
+                <button
+                  onClick={() => handlePick('directory')}
+                  className="flex-1 py-2 bg-gray-700 hover:bg-gray-600 text-white rounded flex items-center justify-center gap-2"
+                >
+                  <FolderOpen size={16} />
+                  选择目录
+                </button>
+                <button
+                  onClick={() => handlePick('file')}
+                  className="flex-1 py-2 bg-gray-700 hover:bg-gray-600 text-white rounded flex items-center justify-center gap-2"
+                >
+                  <FileCode size={16} />
+                  选择文件或 ZIP
+                </button>
+              </div>
+              {pickedSource && pickedSource.kind !== 'url' && (
+                <p className="text-white text-sm mt-2 break-all">{pickedSource.path}</p>
+              )}
+              <p className="text-gray-500 text-xs mt-1">
+                文件之间的 $ref 会被解析
+              </p>
+            </div>
+          )}
+
+          {mode === 'upload' && (
+            <>
+              <div>
+                <label className="block text-sm text-gray-300 mb-2">
+                  选择文件
+                </label>
+                <div className="flex items-center gap-3">
+                  <label className="flex-1 cursor-pointer">
+                    <div className="border-2 border-dashed border-gray-600 rounded-lg p-6 hover:border-blue-500 transition-colors">
+                      <div className="flex flex-col items-center gap-2">
+                        {fileName ? (
+                          <>
+                            {format === 'json' ? (
+                              <FileJson size={32} className="text-blue-500" />
+                            ) : (
+                              <FileCode size={32} className="text-green-500" />
+                            )}
+                            <p className="text-white text-sm">{fileName}</p>
+                            <p className="text-gray-500 text-xs">
+                              {format.toUpperCase()} 格式
+                            </p>
+                        </>
+                      ) : (
+                        <>
+                          <Upload size={32} className="text-gray-500" />
+                          <p className="text-gray-400 text-sm">
+                            点击选择文件或拖拽到此处
+                          </p>
+                          <p className="text-gray-500 text-xs">
+                            支持 .json, .yml, .yaml 文件
+                          </p>
+                        </>
+                      )}
+                    </div>
+                  </div>
+                  <input
+                    type="file"
+                    accept=".json,.yml,.yaml"
+                    onChange={handleFileSelect}
+                    className="hidden"
+                  />
+                </label>
+              </div>
+            </div>
+
+            <div>
+              <label className="block text-sm text-gray-300 mb-1">
+                文件格式
               </label>
+              <div className="flex gap-4">
+                <label className="flex items-center gap-2 cursor-pointer">
+                  <input
+                    type="radio"
+                    name="format"
+                    value="json"
+                    checked={format === 'json'}
+                    onChange={(e) => setFormat(e.target.value as 'json')}
+                    className="text-blue-600"
+                  />
+                  <span className="text-white">JSON</span>
+                </label>
+                <label className="flex items-center gap-2 cursor-pointer">
+                  <input
+                    type="radio"
+                    name="format"
+                    value="yaml"
+                    checked={format === 'yaml'}
+                    onChange={(e) => setFormat(e.target.value as 'yaml')}
+                    className="text-blue-600"
+                  />
+                  <span className="text-white">YAML</span>
+                </label>
+              </div>
             </div>
-          </div>
+            </>
+          )}
 
           {!preselectedProjectId && (
             <div>
@@ -182,29 +332,31 @@ export default function ImportDialog({ projects, onImport, onClose, preselectedP
             </div>
           )}
 
-          <div>
-            <label className="block text-sm text-gray-300 mb-1">
-              Base URL（可选）
-            </label>
-            <input
-              type="text"
-              value={baseURL}
-              onChange={(e) => setBaseURL(e.target.value)}
-              placeholder="例如: https://api.example.com"
-              className="w-full px-3 py-2 bg-gray-700 border border-gray-600 rounded text-white placeholder-gray-400"
-            />
-            <p className="text-gray-500 text-xs mt-1">
-              如果 OpenAPI 文件中未定义服务器地址，将使用此 URL
-            </p>
-          </div>
+          {mode === 'upload' && (
+            <div>
+              <label className="block text-sm text-gray-300 mb-1">
+                Base URL（可选）
+              </label>
+              <input
+                type="text"
+                value={baseURL}
+                onChange={(e) => setBaseURL(e.target.value)}
+                placeholder="例如: https://api.example.com"
+                className="w-full px-3 py-2 bg-gray-700 border border-gray-600 rounded text-white placeholder-gray-400"
+              />
+              <p className="text-gray-500 text-xs mt-1">
+                如果 OpenAPI 文件中未定义服务器地址，将使用此 URL
+              </p>
+            </div>
+          )}
         </div>
 
         <div className="flex gap-3 mt-6">
           <button
             onClick={handleImport}
-            disabled={!fileContent}
+            disabled={!canImport}
             className={`flex-1 py-2 rounded ${
-              fileContent
+              canImport
                 ? 'bg-blue-600 hover:bg-blue-700 text-white'
                 : 'bg-gray-700 text-gray-500 cursor-not-allowed'
             }`}
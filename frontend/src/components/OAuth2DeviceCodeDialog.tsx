@@ -0,0 +1,67 @@
+import { X, Smartphone } from 'lucide-react';
+import { BrowserOpenURL } from '../../wailsjs/runtime/runtime';
+
+export interface OAuth2DeviceCode {
+  id: string;
+  userCode: string;
+  verificationUri: string;
+  verificationUriComplete?: string;
+  expiresIn: number;
+}
+
+interface OAuth2DeviceCodeDialogProps {
+  deviceCode: OAuth2DeviceCode;
+  onCancel: () => void;
+}
+
+export default function OAuth2DeviceCodeDialog({ deviceCode, onCancel }: OAuth2DeviceCodeDialogProps) {
+  const verificationUri = deviceCode.verificationUriComplete || deviceCode.verificationUri;
+
+  return (
+    <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50">
+      <div className="bg-gray-800 rounded-lg p-6 w-[480px]">
+        <div className="flex items-center justify-between mb-4">
+          <h2 className="text-xl font-semibold text-white flex items-center gap-2">
+            <Smartphone size={24} />
+            设备授权
+          </h2>
+          <button
+            onClick={onCancel}
+            className="text-gray-400 hover:text-white"
+          >
+            <X size={24} />
+          </button>
+        </div>
+
+        <p className="text-sm text-gray-300 mb-4">
+          在浏览器中打开以下地址并输入用户码完成授权，授权完成后此窗口会自动关闭。
+        </p>
+
+        <div className="bg-gray-900 rounded px-4 py-3 mb-4 text-center">
+          <div className="text-xs text-gray-500 mb-1">用户码</div>
+          <div className="text-3xl font-mono tracking-widest text-white select-all">{deviceCode.userCode}</div>
+        </div>
+
+        <button
+          onClick={() => BrowserOpenURL(verificationUri)}
+          className="w-full text-left px-3 py-2 bg-gray-900 rounded text-blue-400 hover:text-blue-300 text-sm font-mono break-all"
+        >
+          {deviceCode.verificationUri}
+        </button>
+
+        {deviceCode.expiresIn > 0 && (
+          <p className="mt-2 text-xs text-gray-500">用户码 {Math.round(deviceCode.expiresIn / 60)} 分钟内有效</p>
+        )}
+
+        <div className="flex gap-3 mt-6">
+          <button
+            onClick={onCancel}
+            className="flex-1 py-2 bg-gray-700 hover:bg-gray-600 text-white rounded"
+          >
+            取消授权
+          </button>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,132 @@
+import { useState } from 'react';
+import { X, RefreshCw } from 'lucide-react';
+import { main } from '../../wailsjs/go/models';
+
+interface OpenAPISyncDialogProps {
+  title: string;
+  diff: main.OpenAPISyncDiff;
+  applied?: boolean;
+  error?: string;
+  onApply?: (removeMissing: boolean, adoptMatching: boolean) => void;
+  onClose: () => void;
+}
+
+function OperationList({ label, color, operations }: { label: string; color: string; operations: main.OpenAPIOperationDiff[] }) {
+  if (!operations || operations.length === 0) return null;
+
+  return (
+    <div>
+      <div className={`text-sm font-semibold mb-1 ${color}`}>
+        {label} ({operations.length})
+      </div>
+      <div className="space-y-1">
+        {operations.map((op) => (
+          <div key={op.operationKey + (op.requestId || '')} className="bg-gray-700 rounded px-3 py-2">
+            <div className="flex items-center gap-2 text-sm">
+              <span className="font-mono text-xs text-gray-300 w-16">{op.method}</span>
+              <span className="text-white truncate">{op.name || op.path}</span>
+            </div>
+            <div className="font-mono text-xs text-gray-400 truncate">{op.path}</div>
+            {op.changes && op.changes.length > 0 && (
+              <div className="mt-1 font-mono text-xs">
+                {op.changes.map((change) => (
+                  <div key={change} className={change.startsWith('+') ? 'text-green-400' : 'text-red-400'}>
+                    {change}
+                  </div>
+                ))}
+              </div>
+            )}
+          </div>
+        ))}
+      </div>
+    </div>
+  );
+}
+
+export default function OpenAPISyncDialog({ title, diff, applied, error, onApply, onClose }: OpenAPISyncDialogProps) {
+  const [removeMissing, setRemoveMissing] = useState(false);
+  const [adoptMatching, setAdoptMatching] = useState(false);
+  const adopted = diff.adopted || [];
+  const empty = diff.added.length === 0 && diff.changed.length === 0 && diff.removed.length === 0 && adopted.length === 0;
+
+  return (
+    <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50">
+      <div className="bg-gray-800 rounded-lg p-6 w-[700px] max-h-[80vh] flex flex-col">
+        <div className="flex items-center justify-between mb-4">
+          <h2 className="text-xl font-semibold text-white flex items-center gap-2">
+            <RefreshCw size={24} />
+            {title}
+          </h2>
+          <button
+            onClick={onClose}
+            className="text-gray-400 hover:text-white"
+          >
+            <X size={24} />
+          </button>
+        </div>
+
+        {error && (
+          <div className="mb-4 px-3 py-2 bg-red-900 text-red-200 rounded text-sm">{error}</div>
+        )}
+        {applied && (
+          <div className="mb-4 text-sm text-green-400">以下更改已同步到项目</div>
+        )}
+
+        <div className="flex-1 overflow-y-auto space-y-4">
+          <OperationList label="新增" color="text-green-400" operations={diff.added} />
+          <OperationList label="变更" color="text-yellow-400" operations={diff.changed} />
+          <OperationList label="已从规范中移除" color="text-red-400" operations={diff.removed} />
+          <OperationList label="与手动创建的请求同名" color="text-blue-400" operations={adopted} />
+          {empty && !error && (
+            <p className="text-gray-400 text-sm">没有变化</p>
+          )}
+          <p className="text-gray-500 text-xs">{diff.unchanged} 个接口未变化</p>
+        </div>
+
+        {onApply && diff.removed.length > 0 && (
+          <label className="flex items-center gap-2 mt-4 text-sm text-gray-300 cursor-pointer">
+            <input
+              type="checkbox"
+              checked={removeMissing}
+              onChange={(e) => setRemoveMissing(e.target.checked)}
+            />
+            删除规范中已移除的请求
+          </label>
+        )}
+
+        {onApply && adopted.length > 0 && (
+          <label className="flex items-center gap-2 mt-2 text-sm text-gray-300 cursor-pointer">
+            <input
+              type="checkbox"
+              checked={adoptMatching}
+              onChange={(e) => setAdoptMatching(e.target.checked)}
+            />
+            用规范更新同名的手动请求（不勾选则作为新请求添加）
+          </label>
+        )}
+
+        <div className="flex gap-3 mt-6">
+          {onApply && (
+            <button
+              onClick={() => onApply(removeMissing, adoptMatching)}
+              disabled={empty}
+              className={`flex-1 py-2 rounded ${
+                empty
+                  ? 'bg-gray-700 text-gray-500 cursor-not-allowed'
+                  : 'bg-blue-600 hover:bg-blue-700 text-white'
+              }`}
+            >
+              应用
+            </button>
+          )}
+          <button
+            onClick={onClose}
+            className="flex-1 py-2 bg-gray-700 hover:bg-gray-600 text-white rounded"
+          >
+            {onApply ? '取消' : '关闭'}
+          </button>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -1,6 +1,6 @@
 import { useState, useEffect } from 'react';
 import { Folder, Plus, Edit2, Trash2, X, ChevronLeft, ChevronRight, ChevronDown, FileText, Inbox, Download, Play } from 'lucide-react';
-import { Project, HistoryRecord } from '../types';
+import { Project, HistoryRecord, OpenAPISource } from '../types';
 import { main } from '../../wailsjs/go/models';
 import { GetProjectRequests, ExportProjectAPI } from '../../wailsjs/go/main/App';
 import ImportDialog from './ImportDialog';
@@ -17,6 +17,7 @@ interface ProjectSidebarProps {
   onToggleCollapse: () => void;
   onOpenRequest: (request: any) => void;
   onImport: (fileContent: string, format: string, projectId: string, baseURL: string) => void;
+  onImportSource: (source: OpenAPISource, projectId: string) => void;
 }
 
 export default function ProjectSidebar({
@@ -30,6 +31,7 @@ export default function ProjectSidebar({
   onToggleCollapse,
   onOpenRequest,
   onImport,
+  onImportSource,
 }: ProjectSidebarProps) {
   const [showCreateDialog, setShowCreateDialog] = useState(false);
   const [editingProject, setEditingProject] = useState<Project | null>(null);
@@ -123,6 +125,15 @@ export default function ProjectSidebar({
     }
 
     await onImport(fileContent, format, projectId, baseURL);
+    await finishImport(projectId);
+  };
+
+  const handleImportSource = async (source: OpenAPISource, projectId: string) => {
+    await onImportSource(source, projectId);
+    await finishImport(projectId);
+  };
+
+  const finishImport = async (projectId: string) => {
     setShowImportDialog(false);
     
     if (projectId) {
@@ -460,6 +471,7 @@ export default function ProjectSidebar({
         <ImportDialog
           projects={projects}
           onImport={handleImport}
+          onImportSource={handleImportSource}
           onClose={() => {
             setShowImportDialog(false);
             setImportTargetProjectId('');
@@ -1,24 +1,52 @@
 import { useState } from 'react';
 import { AlertCircle, CheckCircle, XCircle } from 'lucide-react';
-import { HttpResponse } from '../types';
+import { HttpResponse, KeyValue } from '../types';
 
 interface ResponseViewerProps {
   response?: HttpResponse;
   loading: boolean;
+  progress?: { received: number; total: number } | null;
+  onCancel?: () => void;
   error?: string;
 }
 
 type TabType = 'body' | 'headers' | 'cookies' | 'tests';
 type ViewMode = 'formatted' | 'raw';
 
-export default function ResponseViewer({ response, loading, error }: ResponseViewerProps) {
+const formatBytes = (bytes: number) => {
+  if (bytes < 1024) return `${bytes} B`;
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  return `${(bytes / 1024 / 1024).toFixed(1)} MB`;
+};
+
+export default function ResponseViewer({ response, loading, progress, onCancel, error }: ResponseViewerProps) {
   const [activeTab, setActiveTab] = useState<TabType>('body');
   const [viewMode, setViewMode] = useState<ViewMode>('formatted');
   
   if (loading) {
     return (
-      <div className="flex items-center justify-center h-full bg-gray-900">
-        <div className="text-gray-400">Sending request...</div>
+      <div className="flex flex-col items-center justify-center gap-3 h-full bg-gray-900">
+        <div className="text-gray-400">
+          {progress
+            ? `Downloading... ${formatBytes(progress.received)}${progress.total > 0 ? ` / ${formatBytes(progress.total)}` : ''}`
+            : 'Sending request...'}
+        </div>
+        {progress && progress.total > 0 && (
+          <div className="w-64 h-1.5 bg-gray-700 rounded">
+            <div
+              className="h-1.5 bg-blue-500 rounded"
+              style={{ width: `${Math.min(100, (progress.received / progress.total) * 100)}%` }}
+            />
+          </div>
+        )}
+        {onCancel && (
+          <button
+            onClick={onCancel}
+            className="px-4 py-1 bg-gray-700 hover:bg-gray-600 text-white rounded text-sm"
+          >
+            Cancel
+          </button>
+        )}
       </div>
     );
   }
@@ -85,11 +113,11 @@ export default function ResponseViewer({ response, loading, error }: ResponseVie
     }
   };
 
-  const parseCookies = (headers: Record<string, string>): Array<{name: string, value: string}> => {
+  const parseCookies = (headers: KeyValue[]): Array<{name: string, value: string}> => {
     const cookies: Array<{name: string, value: string}> = [];
-    const setCookieHeaders = Object.entries(headers)
-      .filter(([key]) => key.toLowerCase() === 'set-cookie')
-      .map(([, value]) => value);
+    const setCookieHeaders = headers
+      .filter((header) => header.key.toLowerCase() === 'set-cookie')
+      .map((header) => header.value);
     
     setCookieHeaders.forEach(cookieStr => {
       const parts = cookieStr.split(';')[0].split('=');
@@ -129,7 +157,16 @@ export default function ResponseViewer({ response, loading, error }: ResponseVie
             <span className="text-gray-400 text-sm mr-2">Size:</span>
             <span className="text-white">{response.size} bytes</span>
           </div>
+          {response.attempts && response.attempts.length > 1 && (
+            <div>
+              <span className="text-gray-400 text-sm mr-2">Attempts:</span>
+              <span className="text-white">{response.attempts.length}</span>
+            </div>
+          )}
         </div>
+        {response.error && (
+          <div className="mt-2 text-sm text-red-400">{response.error}</div>
+        )}
       </div>
 
       <div className="border-b border-gray-700">
@@ -224,10 +261,10 @@ export default function ResponseViewer({ response, loading, error }: ResponseVie
 
           {activeTab === 'headers' && (
             <div className="bg-gray-800 p-4 rounded">
-              {Object.entries(response.headers || {}).map(([key, value]) => (
-                <div key={key} className="flex gap-2 text-sm mb-1">
-                  <span className="text-blue-400 font-medium">{key}:</span>
-                  <span className="text-gray-200">{String(value)}</span>
+              {(response.headers || []).map((header, index) => (
+                <div key={index} className="flex gap-2 text-sm mb-1">
+                  <span className="text-blue-400 font-medium">{header.key}:</span>
+                  <span className="text-gray-200">{header.value}</span>
                 </div>
               ))}
             </div>
@@ -235,8 +272,8 @@ export default function ResponseViewer({ response, loading, error }: ResponseVie
 
           {activeTab === 'cookies' && (
             <div className="bg-gray-800 p-4 rounded">
-              {parseCookies(response.headers || {}).length > 0 ? (
-                parseCookies(response.headers || {}).map((cookie, index) => (
+              {parseCookies(response.headers || []).length > 0 ? (
+                parseCookies(response.headers || []).map((cookie, index) => (
                   <div key={index} className="flex gap-2 text-sm mb-2 pb-2 border-b border-gray-700 last:border-0">
                     <span className="text-purple-400 font-medium min-w-[120px]">{cookie.name}:</span>
                     <span className="text-gray-200 break-all">{cookie.value}</span>
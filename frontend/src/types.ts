@@ -1,7 +1,7 @@
 import { main } from '../wailsjs/go/models';
 
 export type HttpMethod = 'GET' | 'POST' | 'PUT' | 'DELETE' | 'PATCH' | 'HEAD' | 'OPTIONS';
-export type AuthType = 'none' | 'basic' | 'bearer' | 'oauth2';
+export type AuthType = 'none' | 'inherit' | 'basic' | 'bearer' | 'oauth2' | 'digest' | 'ntlm' | 'awsv4' | 'hawk' | 'apikey' | 'jwt';
 
 export type HttpRequest = main.HttpRequest;
 export type HttpResponse = main.HttpResponse;
@@ -23,3 +23,11 @@ export interface Tab {
   response?: HttpResponse;
   error?: string;
 }
+
+// OpenAPISource is where a spec is imported from besides an uploaded file:
+// a URL, a directory of a spec split across files, or a spec file or zip
+// archive picked from disk.
+export type OpenAPISource =
+  | { kind: 'url'; url: string; headers: KeyValue[] }
+  | { kind: 'directory'; path: string }
+  | { kind: 'file'; path: string };
@@ -2,20 +2,28 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function CancelRequest(arg1:string):Promise<boolean>;
+
 export function ClearHistory():Promise<void>;
 
+export function ClearOAuth2Tokens():Promise<void>;
+
 export function CreateProject(arg1:main.Project):Promise<void>;
 
 export function DeleteEnvironment(arg1:string):Promise<void>;
 
 export function DeleteHistoryRecord(arg1:string):Promise<void>;
 
+export function DeleteOAuth2Token(arg1:string):Promise<void>;
+
 export function DeleteProject(arg1:string):Promise<void>;
 
 export function DeleteRequest(arg1:string):Promise<void>;
 
 export function DeleteToken(arg1:string):Promise<void>;
 
+export function DiscoverOIDC(arg1:main.Auth):Promise<main.Auth>;
+
 export function ExchangeOAuth2Code(arg1:main.Auth,arg2:string):Promise<main.Auth>;
 
 export function ExportAllData():Promise<string>;
@@ -36,8 +44,16 @@ export function GetHistory(arg1:number):Promise<Array<main.HistoryRecord>>;
 
 export function GetOAuth2ClientCredentialsToken(arg1:main.Auth):Promise<main.Auth>;
 
+export function GetOAuth2JwtBearerToken(arg1:main.Auth):Promise<main.Auth>;
+
 export function GetOAuth2PasswordToken(arg1:main.Auth,arg2:string,arg3:string):Promise<main.Auth>;
 
+export function GetOAuth2TokenExchangeToken(arg1:main.Auth):Promise<main.Auth>;
+
+export function GetOAuth2Tokens():Promise<Array<main.OAuth2CachedToken>>;
+
+export function GetOIDCUserInfo(arg1:main.Auth):Promise<Record<string, any>>;
+
 export function GetProject(arg1:string):Promise<main.Project>;
 
 export function GetProjectRequests(arg1:string):Promise<Array<main.HistoryRecord>>;
@@ -50,6 +66,12 @@ export function ImportAllData():Promise<void>;
 
 export function ImportOpenAPI(arg1:string,arg2:string,arg3:string,arg4:string):Promise<Array<main.HttpRequest>>;
 
+export function ImportOpenAPIDirectory(arg1:string,arg2:string):Promise<Array<main.HttpRequest>>;
+
+export function ImportOpenAPIFile(arg1:string,arg2:string,arg3:string):Promise<Array<main.HttpRequest>>;
+
+export function ImportOpenAPIFromURL(arg1:string,arg2:Array<main.KeyValue>,arg3:string):Promise<Array<main.HttpRequest>>;
+
 export function ImportProjectAPI(arg1:string):Promise<void>;
 
 export function ImportProjectJSON(arg1:string,arg2:string):Promise<void>;
@@ -64,18 +86,38 @@ export function SaveEnvironment(arg1:main.Environment):Promise<void>;
 
 export function SaveRequest(arg1:main.HttpRequest):Promise<void>;
 
+export function SaveResponseBody(arg1:string):Promise<string>;
+
 export function SaveTabsState(arg1:Array<main.TabState>):Promise<void>;
 
 export function SaveToken(arg1:main.Token):Promise<void>;
 
 export function SearchHistory(arg1:string):Promise<Array<main.HistoryRecord>>;
 
+export function SelectOpenAPIDirectory():Promise<string>;
+
+export function SelectOpenAPIFile():Promise<string>;
+
 export function SendRequest(arg1:main.HttpRequest):Promise<main.HttpResponse>;
 
 export function SetActiveEnvironment(arg1:string):Promise<void>;
 
-export function StartOAuth2Flow(arg1:main.Auth):Promise<void>;
+export function StartOAuth2DeviceFlow(arg1:main.Auth):Promise<main.Auth>;
+
+export function StartOAuth2Flow(arg1:main.Auth):Promise<main.Auth>;
+
+export function SyncOpenAPI(arg1:string,arg2:string,arg3:string,arg4:main.OpenAPISyncOptions):Promise<main.OpenAPISyncDiff>;
+
+export function SyncOpenAPIFile(arg1:string,arg2:string,arg3:main.OpenAPISyncOptions):Promise<main.OpenAPISyncDiff>;
+
+export function SyncOpenAPIURL(arg1:string,arg2:Array<main.KeyValue>,arg3:string,arg4:main.OpenAPISyncOptions):Promise<main.OpenAPISyncDiff>;
+
+export function UnwatchOpenAPI(arg1:string):Promise<void>;
 
 export function UpdateProject(arg1:main.Project):Promise<void>;
 
 export function UpdateRequest(arg1:main.HttpRequest):Promise<void>;
+
+export function WatchOpenAPIFile(arg1:string,arg2:string,arg3:main.OpenAPISyncOptions):Promise<void>;
+
+export function WatchOpenAPIURL(arg1:string,arg2:Array<main.KeyValue>,arg3:string,arg4:main.OpenAPISyncOptions):Promise<void>;
@@ -2,10 +2,18 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelRequest(arg1) {
+  return window['go']['main']['App']['CancelRequest'](arg1);
+}
+
 export function ClearHistory() {
   return window['go']['main']['App']['ClearHistory']();
 }
 
+export function ClearOAuth2Tokens() {
+  return window['go']['main']['App']['ClearOAuth2Tokens']();
+}
+
 export function CreateProject(arg1) {
   return window['go']['main']['App']['CreateProject'](arg1);
 }
@@ -18,6 +26,10 @@ export function DeleteHistoryRecord(arg1) {
   return window['go']['main']['App']['DeleteHistoryRecord'](arg1);
 }
 
+export function DeleteOAuth2Token(arg1) {
+  return window['go']['main']['App']['DeleteOAuth2Token'](arg1);
+}
+
 export function DeleteProject(arg1) {
   return window['go']['main']['App']['DeleteProject'](arg1);
 }
@@ -30,6 +42,10 @@ export function DeleteToken(arg1) {
   return window['go']['main']['App']['DeleteToken'](arg1);
 }
 
+export function DiscoverOIDC(arg1) {
+  return window['go']['main']['App']['DiscoverOIDC'](arg1);
+}
+
 export function ExchangeOAuth2Code(arg1, arg2) {
   return window['go']['main']['App']['ExchangeOAuth2Code'](arg1, arg2);
 }
@@ -70,10 +86,26 @@ export function GetOAuth2ClientCredentialsToken(arg1) {
   return window['go']['main']['App']['GetOAuth2ClientCredentialsToken'](arg1);
 }
 
+export function GetOAuth2JwtBearerToken(arg1) {
+  return window['go']['main']['App']['GetOAuth2JwtBearerToken'](arg1);
+}
+
 export function GetOAuth2PasswordToken(arg1, arg2, arg3) {
   return window['go']['main']['App']['GetOAuth2PasswordToken'](arg1, arg2, arg3);
 }
 
+export function GetOAuth2TokenExchangeToken(arg1) {
+  return window['go']['main']['App']['GetOAuth2TokenExchangeToken'](arg1);
+}
+
+export function GetOAuth2Tokens() {
+  return window['go']['main']['App']['GetOAuth2Tokens']();
+}
+
+export function GetOIDCUserInfo(arg1) {
+  return window['go']['main']['App']['GetOIDCUserInfo'](arg1);
+}
+
 export function GetProject(arg1) {
   return window['go']['main']['App']['GetProject'](arg1);
 }
@@ -98,6 +130,18 @@ export function ImportOpenAPI(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['ImportOpenAPI'](arg1, arg2, arg3, arg4);
 }
 
+export function ImportOpenAPIDirectory(arg1, arg2) {
+  return window['go']['main']['App']['ImportOpenAPIDirectory'](arg1, arg2);
+}
+
+export function ImportOpenAPIFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportOpenAPIFile'](arg1, arg2, arg3);
+}
+
+export function ImportOpenAPIFromURL(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportOpenAPIFromURL'](arg1, arg2, arg3);
+}
+
 export function ImportProjectAPI(arg1) {
   return window['go']['main']['App']['ImportProjectAPI'](arg1);
 }
@@ -126,6 +170,10 @@ export function SaveRequest(arg1) {
   return window['go']['main']['App']['SaveRequest'](arg1);
 }
 
+export function SaveResponseBody(arg1) {
+  return window['go']['main']['App']['SaveResponseBody'](arg1);
+}
+
 export function SaveTabsState(arg1) {
   return window['go']['main']['App']['SaveTabsState'](arg1);
 }
@@ -138,6 +186,14 @@ export function SearchHistory(arg1) {
   return window['go']['main']['App']['SearchHistory'](arg1);
 }
 
+export function SelectOpenAPIDirectory() {
+  return window['go']['main']['App']['SelectOpenAPIDirectory']();
+}
+
+export function SelectOpenAPIFile() {
+  return window['go']['main']['App']['SelectOpenAPIFile']();
+}
+
 export function SendRequest(arg1) {
   return window['go']['main']['App']['SendRequest'](arg1);
 }
@@ -146,10 +202,30 @@ export function SetActiveEnvironment(arg1) {
   return window['go']['main']['App']['SetActiveEnvironment'](arg1);
 }
 
+export function StartOAuth2DeviceFlow(arg1) {
+  return window['go']['main']['App']['StartOAuth2DeviceFlow'](arg1);
+}
+
 export function StartOAuth2Flow(arg1) {
   return window['go']['main']['App']['StartOAuth2Flow'](arg1);
 }
 
+export function SyncOpenAPI(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SyncOpenAPI'](arg1, arg2, arg3, arg4);
+}
+
+export function SyncOpenAPIFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SyncOpenAPIFile'](arg1, arg2, arg3);
+}
+
+export function SyncOpenAPIURL(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SyncOpenAPIURL'](arg1, arg2, arg3, arg4);
+}
+
+export function UnwatchOpenAPI(arg1) {
+  return window['go']['main']['App']['UnwatchOpenAPI'](arg1);
+}
+
 export function UpdateProject(arg1) {
   return window['go']['main']['App']['UpdateProject'](arg1);
 }
@@ -157,3 +233,11 @@ export function UpdateProject(arg1) {
 export function UpdateRequest(arg1) {
   return window['go']['main']['App']['UpdateRequest'](arg1);
 }
+
+export function WatchOpenAPIFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['WatchOpenAPIFile'](arg1, arg2, arg3);
+}
+
+export function WatchOpenAPIURL(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['WatchOpenAPIURL'](arg1, arg2, arg3, arg4);
+}
@@ -1,5 +1,29 @@
 export namespace main {
 	
+	export class KeyValue {
+	    key: string;
+	    value: string;
+	    enabled: boolean;
+	    type?: string;
+	    filePath?: string;
+	    contentType?: string;
+	    description?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new KeyValue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.value = source["value"];
+	        this.enabled = source["enabled"];
+	        this.type = source["type"];
+	        this.filePath = source["filePath"];
+	        this.contentType = source["contentType"];
+	        this.description = source["description"];
+	    }
+	}
 	export class Auth {
 	    type: string;
 	    username?: string;
@@ -14,6 +38,47 @@ export namespace main {
 	    oauth2RedirectUrl?: string;
 	    oauth2AccessToken?: string;
 	    oauth2RefreshToken?: string;
+	    oauth2TokenType?: string;
+	    oauth2ExpiresAt?: number;
+	    oauth2Issuer?: string;
+	    oauth2IdToken?: string;
+	    oauth2IdTokenClaims?: Record<string, any>;
+	    oauth2ClientAuthMethod?: string;
+	    oauth2ClientPrivateKey?: string;
+	    oauth2ClientKeyId?: string;
+	    oauth2ExtraParams?: KeyValue[];
+	    oauth2ExtraHeaders?: KeyValue[];
+	    oauth2Username?: string;
+	    oauth2DeviceAuthUrl?: string;
+	    oauth2SubjectToken?: string;
+	    oauth2SubjectTokenType?: string;
+	    oauth2ActorToken?: string;
+	    oauth2ActorTokenType?: string;
+	    oauth2RequestedTokenType?: string;
+	    oauth2Audience?: string;
+	    oauth2Resource?: string;
+	    oauth2Assertion?: string;
+	    domain?: string;
+	    awsAccessKey?: string;
+	    awsSecretKey?: string;
+	    awsSessionToken?: string;
+	    awsRegion?: string;
+	    awsService?: string;
+	    hawkId?: string;
+	    hawkKey?: string;
+	    hawkAlgorithm?: string;
+	    hawkExt?: string;
+	    apiKeyName?: string;
+	    apiKeyValue?: string;
+	    apiKeyIn?: string;
+	    jwtAlgorithm?: string;
+	    jwtSecret?: string;
+	    jwtSecretBase64?: boolean;
+	    jwtPrivateKey?: string;
+	    jwtHeader?: string;
+	    jwtPayload?: string;
+	    jwtHeaderName?: string;
+	    jwtTokenPrefix?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Auth(source);
@@ -34,6 +99,85 @@ export namespace main {
 	        this.oauth2RedirectUrl = source["oauth2RedirectUrl"];
 	        this.oauth2AccessToken = source["oauth2AccessToken"];
 	        this.oauth2RefreshToken = source["oauth2RefreshToken"];
+	        this.oauth2TokenType = source["oauth2TokenType"];
+	        this.oauth2ExpiresAt = source["oauth2ExpiresAt"];
+	        this.oauth2Issuer = source["oauth2Issuer"];
+	        this.oauth2IdToken = source["oauth2IdToken"];
+	        this.oauth2IdTokenClaims = source["oauth2IdTokenClaims"];
+	        this.oauth2ClientAuthMethod = source["oauth2ClientAuthMethod"];
+	        this.oauth2ClientPrivateKey = source["oauth2ClientPrivateKey"];
+	        this.oauth2ClientKeyId = source["oauth2ClientKeyId"];
+	        this.oauth2ExtraParams = this.convertValues(source["oauth2ExtraParams"], KeyValue);
+	        this.oauth2ExtraHeaders = this.convertValues(source["oauth2ExtraHeaders"], KeyValue);
+	        this.oauth2Username = source["oauth2Username"];
+	        this.oauth2DeviceAuthUrl = source["oauth2DeviceAuthUrl"];
+	        this.oauth2SubjectToken = source["oauth2SubjectToken"];
+	        this.oauth2SubjectTokenType = source["oauth2SubjectTokenType"];
+	        this.oauth2ActorToken = source["oauth2ActorToken"];
+	        this.oauth2ActorTokenType = source["oauth2ActorTokenType"];
+	        this.oauth2RequestedTokenType = source["oauth2RequestedTokenType"];
+	        this.oauth2Audience = source["oauth2Audience"];
+	        this.oauth2Resource = source["oauth2Resource"];
+	        this.oauth2Assertion = source["oauth2Assertion"];
+	        this.domain = source["domain"];
+	        this.awsAccessKey = source["awsAccessKey"];
+	        this.awsSecretKey = source["awsSecretKey"];
+	        this.awsSessionToken = source["awsSessionToken"];
+	        this.awsRegion = source["awsRegion"];
+	        this.awsService = source["awsService"];
+	        this.hawkId = source["hawkId"];
+	        this.hawkKey = source["hawkKey"];
+	        this.hawkAlgorithm = source["hawkAlgorithm"];
+	        this.hawkExt = source["hawkExt"];
+	        this.apiKeyName = source["apiKeyName"];
+	        this.apiKeyValue = source["apiKeyValue"];
+	        this.apiKeyIn = source["apiKeyIn"];
+	        this.jwtAlgorithm = source["jwtAlgorithm"];
+	        this.jwtSecret = source["jwtSecret"];
+	        this.jwtSecretBase64 = source["jwtSecretBase64"];
+	        this.jwtPrivateKey = source["jwtPrivateKey"];
+	        this.jwtHeader = source["jwtHeader"];
+	        this.jwtPayload = source["jwtPayload"];
+	        this.jwtHeaderName = source["jwtHeaderName"];
+	        this.jwtTokenPrefix = source["jwtTokenPrefix"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RetryAttempt {
+	    attempt: number;
+	    status?: number;
+	    error?: string;
+	    duration: number;
+	    delay?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetryAttempt(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.attempt = source["attempt"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.duration = source["duration"];
+	        this.delay = source["delay"];
 	    }
 	}
 	export class TestResult {
@@ -65,6 +209,7 @@ export namespace main {
 	    tests?: TestResult[];
 	    passedTests: number;
 	    failedTests: number;
+	    attempts?: RetryAttempt[];
 	
 	    static createFrom(source: any = {}) {
 	        return new RequestRunResult(source);
@@ -84,6 +229,7 @@ export namespace main {
 	        this.tests = this.convertValues(source["tests"], TestResult);
 	        this.passedTests = source["passedTests"];
 	        this.failedTests = source["failedTests"];
+	        this.attempts = this.convertValues(source["attempts"], RetryAttempt);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -105,6 +251,7 @@ export namespace main {
 		}
 	}
 	export class CollectionRunResult {
+	    id: string;
 	    projectId: string;
 	    projectName: string;
 	    // Go type: time
@@ -115,6 +262,7 @@ export namespace main {
 	    totalTests: number;
 	    passedTests: number;
 	    failedTests: number;
+	    cancelled: boolean;
 	    requestResults: RequestRunResult[];
 	
 	    static createFrom(source: any = {}) {
@@ -123,6 +271,7 @@ export namespace main {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
 	        this.projectId = source["projectId"];
 	        this.projectName = source["projectName"];
 	        this.startTime = this.convertValues(source["startTime"], null);
@@ -131,6 +280,7 @@ export namespace main {
 	        this.totalTests = source["totalTests"];
 	        this.passedTests = source["passedTests"];
 	        this.failedTests = source["failedTests"];
+	        this.cancelled = source["cancelled"];
 	        this.requestResults = this.convertValues(source["requestResults"], RequestRunResult);
 	    }
 	
@@ -152,6 +302,26 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class ConnectionInfo {
+	    remoteAddr?: string;
+	    protocol?: string;
+	    tlsVersion?: string;
+	    tlsCipher?: string;
+	    reused: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnectionInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.remoteAddr = source["remoteAddr"];
+	        this.protocol = source["protocol"];
+	        this.tlsVersion = source["tlsVersion"];
+	        this.tlsCipher = source["tlsCipher"];
+	        this.reused = source["reused"];
+	    }
+	}
 	export class Environment {
 	    id: string;
 	    name: string;
@@ -202,14 +372,72 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class ResponseTiming {
+	    dnsLookup: number;
+	    tcpConnect: number;
+	    tlsHandshake: number;
+	    firstByte: number;
+	    download: number;
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ResponseTiming(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dnsLookup = source["dnsLookup"];
+	        this.tcpConnect = source["tcpConnect"];
+	        this.tlsHandshake = source["tlsHandshake"];
+	        this.firstByte = source["firstByte"];
+	        this.download = source["download"];
+	        this.total = source["total"];
+	    }
+	}
+	export class ResponsePreview {
+	    kind: string;
+	    mimeType: string;
+	    width?: number;
+	    height?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ResponsePreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.mimeType = source["mimeType"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
 	export class HttpResponse {
+	    id?: string;
 	    status: number;
 	    statusText: string;
-	    headers: Record<string, string>;
+	    headers: KeyValue[];
 	    body: string;
+	    bodyFile?: string;
+	    truncated?: boolean;
+	    bodyEncoding?: string;
+	    isBinary?: boolean;
+	    contentType?: string;
+	    charset?: string;
+	    preview?: ResponsePreview;
 	    size: number;
+	    compressedSize?: number;
+	    contentEncoding?: string;
+	    decompressed?: boolean;
 	    time: number;
+	    timing?: ResponseTiming;
+	    connection?: ConnectionInfo;
 	    scriptResult?: ScriptResult;
+	    requestBodySize?: number;
+	    requestCompressedSize?: number;
+	    attempts?: RetryAttempt[];
+	    error?: string;
+	    authSource?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new HttpResponse(source);
@@ -217,13 +445,105 @@ export namespace main {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
 	        this.status = source["status"];
 	        this.statusText = source["statusText"];
-	        this.headers = source["headers"];
+	        this.headers = this.convertValues(source["headers"], KeyValue);
 	        this.body = source["body"];
+	        this.bodyFile = source["bodyFile"];
+	        this.truncated = source["truncated"];
+	        this.bodyEncoding = source["bodyEncoding"];
+	        this.isBinary = source["isBinary"];
+	        this.contentType = source["contentType"];
+	        this.charset = source["charset"];
+	        this.preview = this.convertValues(source["preview"], ResponsePreview);
 	        this.size = source["size"];
+	        this.compressedSize = source["compressedSize"];
+	        this.contentEncoding = source["contentEncoding"];
+	        this.decompressed = source["decompressed"];
 	        this.time = source["time"];
+	        this.timing = this.convertValues(source["timing"], ResponseTiming);
+	        this.connection = this.convertValues(source["connection"], ConnectionInfo);
 	        this.scriptResult = this.convertValues(source["scriptResult"], ScriptResult);
+	        this.requestBodySize = source["requestBodySize"];
+	        this.requestCompressedSize = source["requestCompressedSize"];
+	        this.attempts = this.convertValues(source["attempts"], RetryAttempt);
+	        this.error = source["error"];
+	        this.authSource = source["authSource"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OpenAPIOrigin {
+	    operationKey: string;
+	    signature?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OpenAPIOrigin(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.operationKey = source["operationKey"];
+	        this.signature = source["signature"];
+	    }
+	}
+	export class RetryPolicy {
+	    maxAttempts: number;
+	    retryOnStatus?: number[];
+	    retryOnNetworkError?: boolean;
+	    initialDelay?: number;
+	    maxDelay?: number;
+	    jitter?: boolean;
+	    ignoreRetryAfter?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetryPolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxAttempts = source["maxAttempts"];
+	        this.retryOnStatus = source["retryOnStatus"];
+	        this.retryOnNetworkError = source["retryOnNetworkError"];
+	        this.initialDelay = source["initialDelay"];
+	        this.maxDelay = source["maxDelay"];
+	        this.jitter = source["jitter"];
+	        this.ignoreRetryAfter = source["ignoreRetryAfter"];
+	    }
+	}
+	export class RequestSettings {
+	    acceptEncoding?: string;
+	    rawResponse?: boolean;
+	    protocol?: string;
+	    retry?: RetryPolicy;
+	
+	    static createFrom(source: any = {}) {
+	        return new RequestSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.acceptEncoding = source["acceptEncoding"];
+	        this.rawResponse = source["rawResponse"];
+	        this.protocol = source["protocol"];
+	        this.retry = this.convertValues(source["retry"], RetryPolicy);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -262,6 +582,9 @@ export namespace main {
 	    type: string;
 	    content?: string;
 	    formData?: KeyValue[];
+	    filePath?: string;
+	    contentType?: string;
+	    compression?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new RequestBody(source);
@@ -272,6 +595,9 @@ export namespace main {
 	        this.type = source["type"];
 	        this.content = source["content"];
 	        this.formData = this.convertValues(source["formData"], KeyValue);
+	        this.filePath = source["filePath"];
+	        this.contentType = source["contentType"];
+	        this.compression = source["compression"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -292,22 +618,6 @@ export namespace main {
 		    return a;
 		}
 	}
-	export class KeyValue {
-	    key: string;
-	    value: string;
-	    enabled: boolean;
-	
-	    static createFrom(source: any = {}) {
-	        return new KeyValue(source);
-	    }
-	
-	    constructor(source: any = {}) {
-	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.key = source["key"];
-	        this.value = source["value"];
-	        this.enabled = source["enabled"];
-	    }
-	}
 	export class HttpRequest {
 	    id: string;
 	    name: string;
@@ -315,10 +625,13 @@ export namespace main {
 	    url: string;
 	    headers: KeyValue[];
 	    params: KeyValue[];
+	    pathParams?: KeyValue[];
 	    body?: RequestBody;
 	    auth?: Auth;
 	    scripts?: Scripts;
+	    settings?: RequestSettings;
 	    projectId?: string;
+	    openapi?: OpenAPIOrigin;
 	
 	    static createFrom(source: any = {}) {
 	        return new HttpRequest(source);
@@ -332,10 +645,13 @@ export namespace main {
 	        this.url = source["url"];
 	        this.headers = this.convertValues(source["headers"], KeyValue);
 	        this.params = this.convertValues(source["params"], KeyValue);
+	        this.pathParams = this.convertValues(source["pathParams"], KeyValue);
 	        this.body = this.convertValues(source["body"], RequestBody);
 	        this.auth = this.convertValues(source["auth"], Auth);
 	        this.scripts = this.convertValues(source["scripts"], Scripts);
+	        this.settings = this.convertValues(source["settings"], RequestSettings);
 	        this.projectId = source["projectId"];
+	        this.openapi = this.convertValues(source["openapi"], OpenAPIOrigin);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -396,11 +712,141 @@ export namespace main {
 	
 	
 	
+	export class OAuth2CachedToken {
+	    key: string;
+	    tokenUrl: string;
+	    clientId?: string;
+	    grantType?: string;
+	    accessToken: string;
+	    refreshToken?: string;
+	    tokenType?: string;
+	    idToken?: string;
+	    scope?: string;
+	    expiresAt?: number;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new OAuth2CachedToken(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.tokenUrl = source["tokenUrl"];
+	        this.clientId = source["clientId"];
+	        this.grantType = source["grantType"];
+	        this.accessToken = source["accessToken"];
+	        this.refreshToken = source["refreshToken"];
+	        this.tokenType = source["tokenType"];
+	        this.idToken = source["idToken"];
+	        this.scope = source["scope"];
+	        this.expiresAt = source["expiresAt"];
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OpenAPIOperationDiff {
+	    operationKey: string;
+	    requestId?: string;
+	    name: string;
+	    method: string;
+	    path: string;
+	    changes?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OpenAPIOperationDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.operationKey = source["operationKey"];
+	        this.requestId = source["requestId"];
+	        this.name = source["name"];
+	        this.method = source["method"];
+	        this.path = source["path"];
+	        this.changes = source["changes"];
+	    }
+	}
+	
+	export class OpenAPISyncDiff {
+	    added: OpenAPIOperationDiff[];
+	    removed: OpenAPIOperationDiff[];
+	    changed: OpenAPIOperationDiff[];
+	    adopted: OpenAPIOperationDiff[];
+	    unchanged: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OpenAPISyncDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added = this.convertValues(source["added"], OpenAPIOperationDiff);
+	        this.removed = this.convertValues(source["removed"], OpenAPIOperationDiff);
+	        this.changed = this.convertValues(source["changed"], OpenAPIOperationDiff);
+	        this.adopted = this.convertValues(source["adopted"], OpenAPIOperationDiff);
+	        this.unchanged = source["unchanged"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OpenAPISyncOptions {
+	    removeMissing: boolean;
+	    dryRun: boolean;
+	    adoptMatching: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new OpenAPISyncOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.removeMissing = source["removeMissing"];
+	        this.dryRun = source["dryRun"];
+	        this.adoptMatching = source["adoptMatching"];
+	    }
+	}
 	export class Project {
 	    id: string;
 	    name: string;
 	    description?: string;
 	    baseUrl?: string;
+	    retry?: RetryPolicy;
+	    auth?: Auth;
 	    // Go type: time
 	    createdAt: any;
 	    // Go type: time
@@ -416,6 +862,8 @@ export namespace main {
 	        this.name = source["name"];
 	        this.description = source["description"];
 	        this.baseUrl = source["baseUrl"];
+	        this.retry = this.convertValues(source["retry"], RetryPolicy);
+	        this.auth = this.convertValues(source["auth"], Auth);
 	        this.createdAt = this.convertValues(source["createdAt"], null);
 	        this.updatedAt = this.convertValues(source["updatedAt"], null);
 	    }
@@ -442,6 +890,11 @@ export namespace main {
 	
 	
 	
+	
+	
+	
+	
+	
 	export class TabState {
 	    id: string;
 	    title: string;
@@ -488,6 +941,12 @@ export namespace main {
 	    createdAt: any;
 	    // Go type: time
 	    updatedAt: any;
+	    autoApply?: boolean;
+	    projectId?: string;
+	    environmentId?: string;
+	    urlPattern?: string;
+	    in?: string;
+	    prefix?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Token(source);
@@ -501,6 +960,12 @@ export namespace main {
 	        this.headerKey = source["headerKey"];
 	        this.createdAt = this.convertValues(source["createdAt"], null);
 	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	        this.autoApply = source["autoApply"];
+	        this.projectId = source["projectId"];
+	        this.environmentId = source["environmentId"];
+	        this.urlPattern = source["urlPattern"];
+	        this.in = source["in"];
+	        this.prefix = source["prefix"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -48,6 +48,7 @@ func (s *HistoryStorage) AddRecord(record HistoryRecord) error {
 
 	for i, existingRecord := range s.history {
 		if isRequestDuplicate(record.Request, existingRecord.Request) {
+			removeResponseFile(existingRecord.Response)
 			s.history = append(s.history[:i], s.history[i+1:]...)
 			break
 		}
@@ -57,6 +58,9 @@ func (s *HistoryStorage) AddRecord(record HistoryRecord) error {
 	s.history = append([]HistoryRecord{record}, s.history...)
 
 	if len(s.history) > 100 {
+		for _, dropped := range s.history[100:] {
+			removeResponseFile(dropped.Response)
+		}
 		s.history = s.history[:100]
 	}
 
@@ -76,6 +80,19 @@ func (s *HistoryStorage) GetHistory(limit int) []HistoryRecord {
 	return result
 }
 
+func (s *HistoryStorage) GetRecord(id string) *HistoryRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, record := range s.history {
+		if record.ID == id {
+			return &record
+		}
+	}
+
+	return nil
+}
+
 func (s *HistoryStorage) SearchHistory(query string) []HistoryRecord {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -102,6 +119,7 @@ func (s *HistoryStorage) DeleteRecord(id string) error {
 
 	for i, record := range s.history {
 		if record.ID == id {
+			removeResponseFile(record.Response)
 			s.history = append(s.history[:i], s.history[i+1:]...)
 			return s.save()
 		}
@@ -114,10 +132,21 @@ func (s *HistoryStorage) ClearHistory() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	for _, record := range s.history {
+		removeResponseFile(record.Response)
+	}
 	s.history = make([]HistoryRecord, 0)
 	return s.save()
 }
 
+// removeResponseFile deletes the spooled body of a response that leaves
+// history or is replaced by a retry before reaching it.
+func removeResponseFile(resp HttpResponse) {
+	if resp.BodyFile != "" {
+		os.Remove(resp.BodyFile)
+	}
+}
+
 func (s *HistoryStorage) load() error {
 	data, err := os.ReadFile(s.filePath)
 	if err != nil {
@@ -185,6 +214,10 @@ func isRequestDuplicate(r1, r2 HttpRequest) bool {
 		return false
 	}
 
+	if !areKeyValuesEqual(r1.PathParams, r2.PathParams) {
+		return false
+	}
+
 	if !areRequestBodiesEqual(r1.Body, r2.Body) {
 		return false
 	}
@@ -193,31 +226,18 @@ func isRequestDuplicate(r1, r2 HttpRequest) bool {
 }
 
 func areKeyValuesEqual(kv1, kv2 []KeyValue) bool {
-	if len(kv1) != len(kv2) {
-		return false
-	}
-
-	map1 := make(map[string]KeyValue)
-	for _, item := range kv1 {
-		if item.Enabled {
-			map1[item.Key] = item
-		}
-	}
-
-	map2 := make(map[string]KeyValue)
-	for _, item := range kv2 {
-		if item.Enabled {
-			map2[item.Key] = item
-		}
-	}
+	enabled1 := enabledKeyValues(kv1)
+	enabled2 := enabledKeyValues(kv2)
 
-	if len(map1) != len(map2) {
+	if len(enabled1) != len(enabled2) {
 		return false
 	}
 
-	for key, val1 := range map1 {
-		val2, exists := map2[key]
-		if !exists || val1.Value != val2.Value {
+	// Order matters: repeated keys are sent in the order they are listed.
+	for i := range enabled1 {
+		if enabled1[i].Key != enabled2[i].Key ||
+			enabled1[i].Value != enabled2[i].Value ||
+			enabled1[i].FilePath != enabled2[i].FilePath {
 			return false
 		}
 	}
@@ -225,6 +245,16 @@ func areKeyValuesEqual(kv1, kv2 []KeyValue) bool {
 	return true
 }
 
+func enabledKeyValues(kvs []KeyValue) []KeyValue {
+	var result []KeyValue
+	for _, item := range kvs {
+		if item.Enabled {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func areRequestBodiesEqual(b1, b2 *RequestBody) bool {
 	if b1 == nil && b2 == nil {
 		return true
@@ -237,7 +267,7 @@ func areRequestBodiesEqual(b1, b2 *RequestBody) bool {
 		return false
 	}
 
-	if b1.Content != b2.Content {
+	if b1.Content != b2.Content || b1.FilePath != b2.FilePath {
 		return false
 	}
 
@@ -2,32 +2,76 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
 type HttpClient struct {
-	client *http.Client
+	client   *http.Client
+	clients  *protocolClients
+	spoolDir string
 }
 
 func NewHttpClient() *HttpClient {
+	// Every protocol's transport bounds dialing and the wait for response
+	// headers (see newBaseTransport and protocolClients); the body is not
+	// bounded so large downloads work, and can be cancelled by the user.
+	// Decompression is done in decodeContentEncoding so it also works when the
+	// user sets Accept-Encoding and covers br and zstd.
+	client := &http.Client{
+		Transport: newBaseTransport(),
+	}
+
+	spoolDir := filepath.Join(os.TempDir(), "postgo-responses")
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		spoolDir = filepath.Join(homeDir, ".postgo", "responses")
+	}
+
 	return &HttpClient{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:   client,
+		clients:  newProtocolClients(client),
+		spoolDir: spoolDir,
 	}
 }
 
-func (h *HttpClient) SendRequest(req HttpRequest) (*HttpResponse, error) {
+func (h *HttpClient) SendRequest(ctx context.Context, id string, req HttpRequest, onProgress func(received, total int64)) (*HttpResponse, error) {
+	var policy *RetryPolicy
+	if req.Settings != nil {
+		policy = req.Settings.Retry
+	}
+	return h.sendWithRetry(ctx, policy, func() (*HttpResponse, error) {
+		resp, err := h.sendOnce(ctx, id, req, onProgress, nil)
+		if err != nil || req.Auth == nil || req.Auth.Type != AuthDigest || resp.Status != http.StatusUnauthorized {
+			return resp, err
+		}
+		// Digest needs the server's nonce, so the first 401 is answered with a second request.
+		challenge := parseDigestChallenge(resp.Headers)
+		if challenge == nil {
+			return resp, nil
+		}
+		removeResponseFile(*resp)
+		return h.sendOnce(ctx, id, req, onProgress, challenge)
+	})
+}
+
+func (h *HttpClient) sendOnce(ctx context.Context, id string, req HttpRequest, onProgress func(received, total int64), challenge *digestChallenge) (*HttpResponse, error) {
 	startTime := time.Now()
 
-	fullURL, err := h.buildURL(req.URL, req.Params)
+	fullURL, err := h.buildURL(req.URL, req.PathParams, req.Params)
 	if err != nil {
 		return nil, err
 	}
@@ -37,84 +81,212 @@ func (h *HttpClient) SendRequest(req HttpRequest) (*HttpResponse, error) {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequest(string(req.Method), fullURL, bodyReader)
+	var compressedBody *compressedRequestBody
+	if bodyReader != nil && req.Body.Compression != "" {
+		if closer, ok := bodyReader.(io.Closer); ok {
+			defer closer.Close()
+		}
+		compressedBody, err = compressRequestBody(bodyReader, req.Body.Compression)
+		if err != nil {
+			return nil, err
+		}
+		bodyReader = compressedBody.reader
+		if closer, ok := bodyReader.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+
+	payloadHash := ""
+	payloadLength := int64(-1)
+	if req.Auth != nil && req.Auth.Type == AuthAWSV4 {
+		_, inMemory := bodyReader.(interface{ Len() int })
+		inMemory = inMemory || compressedBody != nil && compressedBody.contentLength >= 0
+		bodyReader, payloadHash, payloadLength, err = hashPayload(bodyReader, inMemory)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tracer := newRequestTracer()
+	traceCtx := httptrace.WithClientTrace(ctx, tracer.clientTrace())
+
+	httpReq, err := http.NewRequestWithContext(traceCtx, string(req.Method), fullURL, bodyReader)
 	if err != nil {
+		if closer, ok := bodyReader.(io.Closer); ok {
+			closer.Close()
+		}
 		return nil, err
 	}
 
+	if file, ok := bodyReader.(*os.File); ok {
+		if info, err := file.Stat(); err == nil {
+			httpReq.ContentLength = info.Size()
+		}
+	}
+
+	if compressedBody != nil {
+		httpReq.ContentLength = compressedBody.contentLength
+		httpReq.Header.Set("Content-Encoding", strings.ToLower(strings.TrimSpace(req.Body.Compression)))
+	}
+
+	if payloadLength >= 0 {
+		httpReq.ContentLength = payloadLength
+	}
+
 	if contentType != "" {
 		httpReq.Header.Set("Content-Type", contentType)
 	}
 
 	if req.Auth != nil {
-		h.applyAuth(httpReq, req.Auth)
+		if err := h.applyAuth(httpReq, req.Auth, challenge); err != nil {
+			if httpReq.Body != nil {
+				httpReq.Body.Close()
+			}
+			return nil, err
+		}
 	}
 
-	for _, header := range req.Headers {
-		if header.Enabled {
-			httpReq.Header.Set(header.Key, header.Value)
+	applyHeaders(httpReq, req.Headers)
+
+	if httpReq.Header.Get("Accept-Encoding") == "" {
+		acceptEncoding := defaultAcceptEncoding
+		if req.Settings != nil && req.Settings.AcceptEncoding != "" {
+			acceptEncoding = req.Settings.AcceptEncoding
 		}
+		httpReq.Header.Set("Accept-Encoding", acceptEncoding)
 	}
 
-	resp, err := h.client.Do(httpReq)
-	if err != nil {
-		if strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "Timeout") {
-			return nil, fmt.Errorf("请求超时 (30s): %w", err)
+	if req.Auth != nil {
+		if err := h.signRequest(httpReq, req.Auth, payloadHash); err != nil {
+			if httpReq.Body != nil {
+				httpReq.Body.Close()
+			}
+			return nil, err
 		}
-		if strings.Contains(err.Error(), "connection refused") {
-			return nil, fmt.Errorf("连接被拒绝: 无法连接到 %s", httpReq.URL.Host)
+	}
+
+	protocol := ""
+	if req.Settings != nil {
+		protocol = req.Settings.Protocol
+	}
+	if req.Auth != nil && req.Auth.Type == AuthNTLM {
+		protocol = protocolNTLM
+	}
+	if err := checkProtocolScheme(protocol, httpReq.URL.Scheme); err != nil {
+		if httpReq.Body != nil {
+			httpReq.Body.Close()
 		}
-		if strings.Contains(err.Error(), "no such host") {
-			return nil, fmt.Errorf("域名解析失败: %s", httpReq.URL.Host)
+		return nil, err
+	}
+	client, err := h.clients.get(protocol)
+	if err != nil {
+		if httpReq.Body != nil {
+			httpReq.Body.Close()
 		}
-		return nil, fmt.Errorf("请求错误: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	responseBody, err := io.ReadAll(resp.Body)
+	resp, err := client.Do(httpReq)
 	if err != nil {
-		return nil, err
+		return nil, &networkError{err: h.describeError(ctx, httpReq, err)}
 	}
+	defer resp.Body.Close()
 
-	duration := time.Since(startTime).Milliseconds()
+	wire := &progressReader{
+		reader:     resp.Body,
+		total:      resp.ContentLength,
+		lastReport: time.Now(),
+		onProgress: onProgress,
+	}
+	contentEncoding := resp.Header.Get("Content-Encoding")
+	decompress := contentEncoding != "" && (req.Settings == nil || !req.Settings.RawResponse)
+
+	var responseReader io.Reader = wire
+	if decompress {
+		decoded, closeDecoders, err := decodeContentEncoding(wire, contentEncoding)
+		if err != nil {
+			return nil, err
+		}
+		defer closeDecoders()
+		responseReader = decoded
+	}
 
-	headers := make(map[string]string)
-	for key, values := range resp.Header {
-		headers[key] = strings.Join(values, ", ")
+	body, err := readResponseBody(responseReader, h.spoolDir, id)
+	if err != nil {
+		return nil, &networkError{err: h.describeError(ctx, httpReq, err)}
+	}
+	tracer.finish()
+	if onProgress != nil {
+		onProgress(wire.received, resp.ContentLength)
 	}
 
-	return &HttpResponse{
+	duration := time.Since(startTime).Milliseconds()
+
+	httpResp := &HttpResponse{
 		Status:     resp.StatusCode,
 		StatusText: resp.Status,
-		Headers:    headers,
-		Body:       string(responseBody),
-		Size:       int64(len(responseBody)),
+		Headers:    collectResponseHeaders(resp.Header),
+		Size:       body.size,
 		Time:       duration,
-	}, nil
-}
+		Timing:     tracer.timing(),
+		Connection: tracer.connectionInfo(resp.Proto, resp.TLS),
 
-func (h *HttpClient) buildURL(baseURL string, params []KeyValue) (string, error) {
-	if len(params) == 0 {
-		return baseURL, nil
+		ContentEncoding: contentEncoding,
+		Decompressed:    decompress,
+	}
+	if contentEncoding != "" {
+		httpResp.CompressedSize = wire.received
+	}
+	if compressedBody != nil {
+		httpResp.RequestBodySize = compressedBody.raw.count.Load()
+		httpResp.RequestCompressedSize = compressedBody.compressed.count.Load()
 	}
 
-	parsedURL, err := url.Parse(baseURL)
-	if err != nil {
-		return "", err
+	if body.spilled {
+		httpResp.BodyFile = body.file
+		httpResp.Truncated = true
+		decodeResponseBody(trimPartialRune(body.data), resp.Header.Get("Content-Type"), httpResp)
+	} else {
+		httpResp.rawBody = body.data
+		decodeResponseBody(body.data, resp.Header.Get("Content-Type"), httpResp)
 	}
 
-	query := parsedURL.Query()
-	for _, param := range params {
-		if param.Enabled {
-			query.Set(param.Key, param.Value)
+	return httpResp, nil
+}
+
+func (h *HttpClient) describeError(ctx context.Context, httpReq *http.Request, err error) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("请求已取消")
+	}
+	if strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "Timeout") {
+		// Only connecting and waiting for the headers are bounded; report which one ran out.
+		var opErr *net.OpError
+		switch {
+		case strings.Contains(err.Error(), "awaiting response headers"):
+			return fmt.Errorf("等待响应头超时 (%s): %w", responseHeaderTimeout, err)
+		case errors.As(err, &opErr) && opErr.Op == "dial":
+			return fmt.Errorf("连接超时 (%s): %w", dialTimeout, err)
 		}
+		return fmt.Errorf("请求超时: %w", err)
+	}
+	if strings.Contains(err.Error(), "connection refused") {
+		return fmt.Errorf("连接被拒绝: 无法连接到 %s", httpReq.URL.Host)
 	}
+	if strings.Contains(err.Error(), "no such host") {
+		return fmt.Errorf("域名解析失败: %s", httpReq.URL.Host)
+	}
+	return fmt.Errorf("请求错误: %w", err)
+}
 
-	parsedURL.RawQuery = query.Encode()
-	return parsedURL.String(), nil
+func (h *HttpClient) buildURL(baseURL string, pathParams []KeyValue, params []KeyValue) (string, error) {
+	fullURL := appendQueryParams(substitutePathParams(baseURL, pathParams), params)
+	if _, err := url.Parse(fullURL); err != nil {
+		return "", err
+	}
+	return fullURL, nil
 }
 
-func (h *HttpClient) applyAuth(httpReq *http.Request, auth *Auth) {
+func (h *HttpClient) applyAuth(httpReq *http.Request, auth *Auth, challenge *digestChallenge) error {
 	switch auth.Type {
 	case AuthBasic:
 		if auth.Username != "" || auth.Password != "" {
@@ -130,7 +302,39 @@ func (h *HttpClient) applyAuth(httpReq *http.Request, auth *Auth) {
 		if auth.OAuth2AccessToken != "" {
 			httpReq.Header.Set("Authorization", "Bearer "+auth.OAuth2AccessToken)
 		}
+	case AuthDigest:
+		if challenge != nil {
+			httpReq.Header.Set("Authorization", digestAuthorization(httpReq, auth, challenge))
+		}
+	case AuthNTLM:
+		// The NTLM negotiator turns basic credentials into the NTLM handshake.
+		username := auth.Username
+		if auth.Domain != "" && !strings.Contains(username, "\\") {
+			username = auth.Domain + "\\" + username
+		}
+		httpReq.SetBasicAuth(username, auth.Password)
+	case AuthAPIKey:
+		applyAPIKey(httpReq, auth)
+	case AuthJWT:
+		return applyJWT(httpReq, auth)
 	}
+	return nil
+}
+
+// signRequest applies the auth types whose signature covers the final headers,
+// so it runs after the user headers have been added.
+func (h *HttpClient) signRequest(httpReq *http.Request, auth *Auth, payloadHash string) error {
+	switch auth.Type {
+	case AuthAWSV4:
+		signAWSV4(httpReq, auth, payloadHash, time.Now())
+	case AuthHawk:
+		header, err := hawkAuthorization(httpReq, auth, time.Now())
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Authorization", header)
+	}
+	return nil
 }
 
 func (h *HttpClient) buildRequestBody(body *RequestBody) (io.Reader, string, error) {
@@ -173,6 +377,9 @@ func (h *HttpClient) buildRequestBody(body *RequestBody) (io.Reader, string, err
 		if len(body.FormData) == 0 {
 			return nil, "", nil
 		}
+		if hasFileFields(body.FormData) {
+			return h.buildMultipartStream(body.FormData)
+		}
 		var buf bytes.Buffer
 		writer := multipart.NewWriter(&buf)
 		for _, field := range body.FormData {
@@ -189,6 +396,23 @@ func (h *HttpClient) buildRequestBody(body *RequestBody) (io.Reader, string, err
 		}
 		return &buf, writer.FormDataContentType(), nil
 
+	case string(BodyBinary):
+		if body.FilePath == "" {
+			if body.Content == "" {
+				return nil, "", nil
+			}
+			return strings.NewReader(body.Content), "application/octet-stream", nil
+		}
+		file, err := os.Open(body.FilePath)
+		if err != nil {
+			return nil, "", fmt.Errorf("无法打开文件 %s: %w", body.FilePath, err)
+		}
+		contentType := body.ContentType
+		if contentType == "" {
+			contentType = detectFileContentType(body.FilePath)
+		}
+		return file, contentType, nil
+
 	default:
 		if body.Content == "" {
 			return nil, "", nil
@@ -196,3 +420,86 @@ func (h *HttpClient) buildRequestBody(body *RequestBody) (io.Reader, string, err
 		return strings.NewReader(body.Content), "", nil
 	}
 }
+
+// buildMultipartStream writes a multipart body through a pipe so that file parts
+// are streamed from disk instead of being buffered in memory.
+func (h *HttpClient) buildMultipartStream(fields []KeyValue) (io.Reader, string, error) {
+	for _, field := range fields {
+		if field.Enabled && field.Type == string(FormFieldFile) {
+			if _, err := os.Stat(field.FilePath); err != nil {
+				return nil, "", fmt.Errorf("无法读取文件 %s: %w", field.FilePath, err)
+			}
+		}
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		for _, field := range fields {
+			if !field.Enabled {
+				continue
+			}
+			var err error
+			if field.Type == string(FormFieldFile) {
+				err = writeFilePart(writer, field)
+			} else {
+				err = writer.WriteField(field.Key, field.Value)
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(writer.Close())
+	}()
+
+	return pr, writer.FormDataContentType(), nil
+}
+
+func writeFilePart(writer *multipart.Writer, field KeyValue) error {
+	file, err := os.Open(field.FilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	contentType := field.ContentType
+	if contentType == "" {
+		contentType = detectFileContentType(field.FilePath)
+	}
+
+	partHeader := make(textproto.MIMEHeader)
+	partHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(field.Key), escapeQuotes(filepath.Base(field.FilePath))))
+	partHeader.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(partHeader)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, file)
+	return err
+}
+
+func hasFileFields(fields []KeyValue) bool {
+	for _, field := range fields {
+		if field.Enabled && field.Type == string(FormFieldFile) {
+			return true
+		}
+	}
+	return false
+}
+
+func detectFileContentType(path string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const defaultAcceptEncoding = "gzip, deflate, br, zstd"
+
+// countingReader counts the bytes that pass through it. The count is atomic
+// because request bodies are read by the transport's goroutines.
+type countingReader struct {
+	reader io.Reader
+	count  atomic.Int64
+}
+
+func (c *countingReader) Read(buf []byte) (int, error) {
+	n, err := c.reader.Read(buf)
+	c.count.Add(int64(n))
+	return n, err
+}
+
+type compressedRequestBody struct {
+	reader        io.Reader
+	contentLength int64 // -1 when the body is compressed while it is being sent
+	raw           *countingReader
+	compressed    *countingReader
+}
+
+// pipeBody is the body of a request compressed on the fly. Closing it, which
+// the transport does once the body is sent or the request fails, unblocks the
+// compressing goroutine.
+type pipeBody struct {
+	*countingReader
+	pipe *io.PipeReader
+}
+
+func (b *pipeBody) Close() error {
+	return b.pipe.CloseWithError(io.ErrClosedPipe)
+}
+
+// compressRequestBody compresses body with encoding ("gzip", "deflate" or "zstd").
+// In-memory bodies are compressed up front so Content-Length stays known; file
+// streams are compressed on the fly through a pipe.
+func compressRequestBody(body io.Reader, encoding string) (*compressedRequestBody, error) {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	if _, err := newCompressor(io.Discard, encoding); err != nil {
+		return nil, err
+	}
+
+	raw := &countingReader{reader: body}
+
+	if _, inMemory := body.(interface{ Len() int }); inMemory {
+		var buf bytes.Buffer
+		compressor, _ := newCompressor(&buf, encoding)
+		if _, err := io.Copy(compressor, raw); err != nil {
+			return nil, err
+		}
+		if err := compressor.Close(); err != nil {
+			return nil, err
+		}
+		compressed := &countingReader{reader: &buf}
+		return &compressedRequestBody{
+			reader:        compressed,
+			contentLength: int64(buf.Len()),
+			raw:           raw,
+			compressed:    compressed,
+		}, nil
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		compressor, _ := newCompressor(pw, encoding)
+		if _, err := io.Copy(compressor, raw); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(compressor.Close())
+	}()
+
+	compressed := &countingReader{reader: pr}
+	return &compressedRequestBody{
+		reader:        &pipeBody{countingReader: compressed, pipe: pr},
+		contentLength: -1,
+		raw:           raw,
+		compressed:    compressed,
+	}, nil
+}
+
+func newCompressor(w io.Writer, encoding string) (io.WriteCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "deflate":
+		return zlib.NewWriter(w), nil
+	case "zstd":
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("不支持的请求体压缩方式: %s", encoding)
+	}
+}
+
+// decodeContentEncoding wraps body with decoders for the Content-Encoding header.
+// Encodings are listed in the order they were applied, so they are undone in reverse.
+func decodeContentEncoding(body io.Reader, contentEncoding string) (io.Reader, func(), error) {
+	var encodings []string
+	for _, encoding := range strings.Split(contentEncoding, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding != "" && encoding != "identity" {
+			encodings = append(encodings, encoding)
+		}
+	}
+
+	closers := []func(){}
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+
+	if len(encodings) == 0 {
+		return body, closeAll, nil
+	}
+
+	// An empty body (HEAD, 204, 304) can carry Content-Encoding without any data to decode.
+	buffered := bufio.NewReader(body)
+	if _, err := buffered.Peek(1); err == io.EOF {
+		return buffered, closeAll, nil
+	}
+
+	reader := io.Reader(buffered)
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch encodings[i] {
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(reader)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("gzip 解压失败: %w", err)
+			}
+			closers = append(closers, func() { gz.Close() })
+			reader = gz
+		case "deflate":
+			deflateReader := newDeflateReader(reader)
+			closers = append(closers, func() { deflateReader.Close() })
+			reader = deflateReader
+		case "br":
+			reader = brotli.NewReader(reader)
+		case "zstd":
+			decoder, err := zstd.NewReader(reader)
+			if err != nil {
+				closeAll()
+				return nil, nil, fmt.Errorf("zstd 解压失败: %w", err)
+			}
+			closers = append(closers, decoder.Close)
+			reader = decoder
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("不支持的内容编码: %s", encodings[i])
+		}
+	}
+
+	return reader, closeAll, nil
+}
+
+// newDeflateReader handles both zlib-wrapped deflate (what the spec requires)
+// and the raw deflate streams some servers send instead.
+func newDeflateReader(r io.Reader) io.ReadCloser {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		if zr, err := zlib.NewReader(buffered); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(buffered)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// applyHeaders adds the enabled request headers in order. The first occurrence
+// of a name replaces anything set earlier (Content-Type from the body, the auth
+// header); later occurrences are added as extra values.
+func applyHeaders(httpReq *http.Request, headers []KeyValue) {
+	seen := make(map[string]bool)
+	for _, header := range headers {
+		if !header.Enabled || header.Key == "" {
+			continue
+		}
+		name := http.CanonicalHeaderKey(header.Key)
+		if name == "Host" {
+			httpReq.Host = header.Value
+			continue
+		}
+		if !seen[name] {
+			httpReq.Header.Del(name)
+			seen[name] = true
+		}
+		httpReq.Header.Add(name, header.Value)
+	}
+}
+
+// collectResponseHeaders flattens the response headers into one entry per value,
+// so repeated headers such as Set-Cookie stay separate.
+func collectResponseHeaders(header http.Header) []KeyValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := make([]KeyValue, 0, len(names))
+	for _, name := range names {
+		for _, value := range header[name] {
+			headers = append(headers, KeyValue{Key: name, Value: value, Enabled: true})
+		}
+	}
+	return headers
+}
+
+// headerMap joins repeated values for consumers that only need one value per name.
+func headerMap(headers []KeyValue) map[string]string {
+	result := make(map[string]string)
+	for _, header := range headers {
+		if existing, ok := result[header.Key]; ok {
+			result[header.Key] = existing + ", " + header.Value
+		} else {
+			result[header.Key] = header.Value
+		}
+	}
+	return result
+}
+
+// appendQueryParams appends the enabled params to rawURL without re-encoding the
+// query string that is already there, so repeated keys and their order survive.
+func appendQueryParams(rawURL string, params []KeyValue) string {
+	var pairs []string
+	for _, param := range params {
+		if param.Enabled {
+			pairs = append(pairs, url.QueryEscape(param.Key)+"="+url.QueryEscape(param.Value))
+		}
+	}
+	if len(pairs) == 0 {
+		return rawURL
+	}
+
+	fragment := ""
+	if i := strings.Index(rawURL, "#"); i >= 0 {
+		rawURL, fragment = rawURL[:i], rawURL[i:]
+	}
+
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+		if strings.HasSuffix(rawURL, "?") || strings.HasSuffix(rawURL, "&") {
+			separator = ""
+		}
+	}
+
+	return rawURL + separator + strings.Join(pairs, "&") + fragment
+}
+
+// substitutePathParams fills :name and {name} placeholders in the path of rawURL
+// with the escaped values of the enabled path params. Placeholders without a
+// value and unresolved {{variables}} are left untouched.
+func substitutePathParams(rawURL string, params []KeyValue) string {
+	values := make(map[string]string)
+	for _, param := range params {
+		if param.Enabled && param.Key != "" && param.Value != "" {
+			values[param.Key] = param.Value
+		}
+	}
+	if len(values) == 0 {
+		return rawURL
+	}
+
+	pathStart := 0
+	if i := strings.Index(rawURL, "://"); i >= 0 {
+		pathStart = i + 3
+		if j := strings.IndexByte(rawURL[pathStart:], '/'); j >= 0 {
+			pathStart += j
+		} else {
+			return rawURL
+		}
+	}
+	pathEnd := len(rawURL)
+	if i := strings.IndexAny(rawURL[pathStart:], "?#"); i >= 0 {
+		pathEnd = pathStart + i
+	}
+	path := rawURL[pathStart:pathEnd]
+
+	var sb strings.Builder
+	for i := 0; i < len(path); {
+		switch {
+		case path[i] == '{' && (i == 0 || path[i-1] != '{'):
+			end := strings.IndexByte(path[i:], '}')
+			if end > 1 && (i+end+1 >= len(path) || path[i+end+1] != '}') {
+				name := path[i+1 : i+end]
+				if value, ok := values[name]; ok {
+					sb.WriteString(url.PathEscape(value))
+					i += end + 1
+					continue
+				}
+			}
+		case path[i] == ':' && i > 0 && path[i-1] == '/':
+			end := i + 1
+			for end < len(path) && isPathParamChar(path[end]) {
+				end++
+			}
+			if value, ok := values[path[i+1:end]]; ok && end > i+1 {
+				sb.WriteString(url.PathEscape(value))
+				i = end
+				continue
+			}
+		}
+		sb.WriteByte(path[i])
+		i++
+	}
+
+	return rawURL[:pathStart] + sb.String() + rawURL[pathEnd:]
+}
+
+func isPathParamChar(c byte) bool {
+	return c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// UnmarshalJSON also accepts the old object form of "headers" so history saved
+// before headers became a list still loads.
+func (r *HttpResponse) UnmarshalJSON(data []byte) error {
+	type plainResponse HttpResponse
+	var aux struct {
+		*plainResponse
+		Headers json.RawMessage `json:"headers"`
+	}
+	aux.plainResponse = (*plainResponse)(r)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	r.Headers = nil
+	if len(aux.Headers) == 0 || string(aux.Headers) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(aux.Headers, &r.Headers); err == nil {
+		return nil
+	}
+
+	var legacy map[string]string
+	if err := json.Unmarshal(aux.Headers, &legacy); err != nil {
+		return err
+	}
+	for name, value := range legacy {
+		r.Headers = append(r.Headers, KeyValue{Key: name, Value: value, Enabled: true})
+	}
+	sort.Slice(r.Headers, func(i, j int) bool {
+		return r.Headers[i].Key < r.Headers[j].Key
+	})
+	return nil
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-ntlmssp"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+)
+
+const (
+	ProtocolAuto  = "auto"  // HTTP/1.1 or HTTP/2, negotiated through ALPN
+	ProtocolHTTP1 = "http1" // HTTP/1.1 only
+	ProtocolHTTP2 = "http2" // HTTP/2 over TLS only
+	ProtocolH2C   = "h2c"   // HTTP/2 over cleartext with prior knowledge
+	ProtocolHTTP3 = "http3" // HTTP/3 over QUIC
+
+	// protocolNTLM is the HTTP/1.1 client wrapped in the NTLM negotiator. NTLM
+	// authenticates the connection, so it can't be combined with another protocol.
+	protocolNTLM = "ntlm"
+)
+
+// protocolClients lazily builds one http.Client per protocol so connections are
+// pooled per protocol and the default client is untouched.
+type protocolClients struct {
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+func newProtocolClients(defaultClient *http.Client) *protocolClients {
+	return &protocolClients{
+		clients: map[string]*http.Client{ProtocolAuto: defaultClient},
+	}
+}
+
+func (p *protocolClients) get(protocol string) (*http.Client, error) {
+	protocol = strings.ToLower(strings.TrimSpace(protocol))
+	if protocol == "" {
+		protocol = ProtocolAuto
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[protocol]; ok {
+		return client, nil
+	}
+
+	var transport http.RoundTripper
+	switch protocol {
+	case ProtocolHTTP1:
+		t := newBaseTransport()
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		t.TLSClientConfig = &tls.Config{NextProtos: []string{"http/1.1"}}
+		transport = t
+	case ProtocolHTTP2:
+		transport = withHeaderTimeout(&http2.Transport{
+			DisableCompression: true,
+			ReadIdleTimeout:    http2ReadIdleTimeout,
+			PingTimeout:        http2PingTimeout,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := dialThroughProxy(ctx, network, addr, "https")
+				if err != nil {
+					return nil, err
+				}
+				tlsConn := tls.Client(conn, cfg)
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					conn.Close()
+					return nil, err
+				}
+				return tlsConn, nil
+			},
+		})
+	case ProtocolH2C:
+		transport = withHeaderTimeout(&http2.Transport{
+			AllowHTTP:          true,
+			DisableCompression: true,
+			ReadIdleTimeout:    http2ReadIdleTimeout,
+			PingTimeout:        http2PingTimeout,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialThroughProxy(ctx, network, addr, "http")
+			},
+		})
+	case protocolNTLM:
+		t := newBaseTransport()
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		transport = ntlmssp.Negotiator{RoundTripper: t}
+	case ProtocolHTTP3:
+		transport = withHeaderTimeout(&http3.Transport{
+			DisableCompression: true,
+			QUICConfig: &quic.Config{
+				HandshakeIdleTimeout: dialTimeout,
+				MaxIdleTimeout:       responseHeaderTimeout,
+				KeepAlivePeriod:      http2ReadIdleTimeout,
+			},
+			Dial: func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlyConnection, error) {
+				// QUIC runs over UDP and can't be tunnelled through an HTTP proxy,
+				// so fail instead of silently bypassing a configured proxy.
+				if proxyURL, _ := proxyForAddr(addr, "https"); proxyURL != nil {
+					return nil, fmt.Errorf("HTTP/3 不支持通过代理 %s 发送请求", proxyURL.Host)
+				}
+				return quic.DialAddrEarly(ctx, addr, tlsCfg, cfg)
+			},
+		})
+	default:
+		return nil, fmt.Errorf("不支持的协议: %s", protocol)
+	}
+
+	client := &http.Client{Transport: transport}
+	p.clients[protocol] = client
+	return client, nil
+}
+
+// checkProtocolScheme rejects combinations the transports can't serve, with a
+// clearer message than the transport error.
+func checkProtocolScheme(protocol string, scheme string) error {
+	switch strings.ToLower(protocol) {
+	case ProtocolHTTP2, ProtocolHTTP3:
+		if scheme != "https" {
+			return fmt.Errorf("%s 需要 https 地址, 明文 HTTP/2 请使用 h2c", protocol)
+		}
+	case ProtocolH2C:
+		if scheme != "http" {
+			return fmt.Errorf("h2c 需要 http 地址")
+		}
+	}
+	return nil
+}
+
+const (
+	dialTimeout           = 30 * time.Second
+	responseHeaderTimeout = 30 * time.Second
+
+	// HTTP/2 connections are health-checked with pings after this much silence.
+	http2ReadIdleTimeout = 15 * time.Second
+	http2PingTimeout     = 15 * time.Second
+)
+
+func newBaseTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+	transport.DisableCompression = true
+	return transport
+}
+
+// headerTimeoutTransport bounds the wait for response headers the way
+// http.Transport.ResponseHeaderTimeout does, for transports without that
+// setting. The body is not bounded so large downloads aren't cut off.
+type headerTimeoutTransport struct {
+	transport http.RoundTripper
+	timeout   time.Duration
+}
+
+func withHeaderTimeout(transport http.RoundTripper) http.RoundTripper {
+	return &headerTimeoutTransport{transport: transport, timeout: responseHeaderTimeout}
+}
+
+func (t *headerTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(t.timeout, cancel)
+
+	resp, err := t.transport.RoundTrip(req.WithContext(ctx))
+	if !timer.Stop() && err != nil && req.Context().Err() == nil {
+		cancel()
+		return nil, fmt.Errorf("timeout awaiting response headers")
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+func proxyForAddr(addr string, scheme string) (*url.URL, error) {
+	return http.ProxyFromEnvironment(&http.Request{URL: &url.URL{Scheme: scheme, Host: addr}})
+}
+
+// dialThroughProxy dials addr directly or, when the environment configures an
+// HTTP proxy for it, through a CONNECT tunnel, as http.Transport does.
+func dialThroughProxy(ctx context.Context, network string, addr string, scheme string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}
+
+	proxyURL, err := proxyForAddr(addr, scheme)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" {
+		return nil, fmt.Errorf("不支持的代理类型: %s", proxyURL.Scheme)
+	}
+
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(dialTimeout))
+	}
+	connectReq := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		connectReq.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+	}
+	if err := connectReq.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connectReq)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("代理连接失败: %s", resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
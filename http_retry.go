@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryInitialDelay = 500
+	defaultRetryMaxDelay     = 30000
+)
+
+type RetryPolicy struct {
+	MaxAttempts         int   `json:"maxAttempts"`                   // total attempts including the first one
+	RetryOnStatus       []int `json:"retryOnStatus,omitempty"`       // e.g. 429, 502, 503
+	RetryOnNetworkError bool  `json:"retryOnNetworkError,omitempty"` // connection refused, resets, timeouts
+	InitialDelay        int   `json:"initialDelay,omitempty"`        // ms before the second attempt, doubled after each retry
+	MaxDelay            int   `json:"maxDelay,omitempty"`            // ms, upper bound for a single wait
+	Jitter              bool  `json:"jitter,omitempty"`
+	IgnoreRetryAfter    bool  `json:"ignoreRetryAfter,omitempty"`
+}
+
+type RetryAttempt struct {
+	Attempt  int    `json:"attempt"`
+	Status   int    `json:"status,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration"`
+	Delay    int64  `json:"delay,omitempty"` // ms waited before the next attempt
+}
+
+// networkError marks failures that happened on the wire, as opposed to errors
+// building the request, so the retry policy knows they are worth retrying.
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string { return e.err.Error() }
+func (e *networkError) Unwrap() error { return e.err }
+
+// RetryError is returned when every attempt failed; it keeps the attempt log.
+type RetryError struct {
+	Err      error
+	Attempts []RetryAttempt
+}
+
+func (e *RetryError) Error() string { return e.Err.Error() }
+func (e *RetryError) Unwrap() error { return e.Err }
+
+func (h *HttpClient) sendWithRetry(ctx context.Context, policy *RetryPolicy, send func() (*HttpResponse, error)) (*HttpResponse, error) {
+	if policy == nil || policy.MaxAttempts <= 1 {
+		resp, err := send()
+		var netErr *networkError
+		if errors.As(err, &netErr) {
+			err = netErr.err
+		}
+		return resp, err
+	}
+
+	var attempts []RetryAttempt
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		resp, err := send()
+
+		record := RetryAttempt{
+			Attempt:  attempt,
+			Duration: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			record.Error = err.Error()
+		} else {
+			record.Status = resp.Status
+		}
+
+		retry := attempt < policy.MaxAttempts && ctx.Err() == nil && policy.shouldRetry(resp, err)
+		if !retry {
+			attempts = append(attempts, record)
+			if err != nil {
+				var netErr *networkError
+				if errors.As(err, &netErr) {
+					err = netErr.err
+				}
+				return nil, &RetryError{Err: err, Attempts: attempts}
+			}
+			resp.Attempts = attempts
+			return resp, nil
+		}
+
+		delay := policy.delay(attempt, resp)
+		record.Delay = delay.Milliseconds()
+		attempts = append(attempts, record)
+		if resp != nil {
+			removeResponseFile(*resp)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, &RetryError{Err: errors.New("请求已取消"), Attempts: attempts}
+		}
+	}
+}
+
+// continueAttempts appends the attempts of a later send to those of an earlier
+// one, numbering them on from where the earlier log ended.
+func continueAttempts(earlier []RetryAttempt, later []RetryAttempt) []RetryAttempt {
+	attempts := make([]RetryAttempt, 0, len(earlier)+len(later))
+	attempts = append(attempts, earlier...)
+	for _, attempt := range later {
+		attempt.Attempt += len(earlier)
+		attempts = append(attempts, attempt)
+	}
+	return attempts
+}
+
+func (p *RetryPolicy) shouldRetry(resp *HttpResponse, err error) bool {
+	if err != nil {
+		var netErr *networkError
+		return p.RetryOnNetworkError && errors.As(err, &netErr)
+	}
+	for _, status := range p.RetryOnStatus {
+		if resp.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// delay is an exponential backoff with optional jitter, overridden by the
+// server's Retry-After header when present.
+func (p *RetryPolicy) delay(attempt int, resp *HttpResponse) time.Duration {
+	initial := p.InitialDelay
+	if initial <= 0 {
+		initial = defaultRetryInitialDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	if resp != nil && !p.IgnoreRetryAfter {
+		if retryAfter, ok := parseRetryAfter(headerValue(resp.Headers, "Retry-After")); ok {
+			return min(retryAfter, time.Duration(maxDelay)*time.Millisecond)
+		}
+	}
+
+	delay := float64(initial) * math.Pow(2, float64(attempt-1))
+	if delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+	if p.Jitter {
+		delay = delay/2 + rand.Float64()*delay/2
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func headerValue(headers []KeyValue, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Key, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+type ResponseTiming struct {
+	DNSLookup    float64 `json:"dnsLookup"`
+	TCPConnect   float64 `json:"tcpConnect"`
+	TLSHandshake float64 `json:"tlsHandshake"`
+	FirstByte    float64 `json:"firstByte"`
+	Download     float64 `json:"download"`
+	Total        float64 `json:"total"`
+}
+
+type ConnectionInfo struct {
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+	Protocol   string `json:"protocol,omitempty"`
+	TLSVersion string `json:"tlsVersion,omitempty"`
+	TLSCipher  string `json:"tlsCipher,omitempty"`
+	Reused     bool   `json:"reused"`
+}
+
+// requestTracer records the phase timestamps reported by httptrace for a single request.
+// Callbacks run on the transport's goroutines, parallel dials included, so
+// every field after start is guarded by mu.
+type requestTracer struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	firstByte    time.Time
+	bodyDone     time.Time
+
+	remoteAddr string
+	reused     bool
+	tlsState   *tls.ConnectionState
+}
+
+func newRequestTracer() *requestTracer {
+	return &requestTracer{start: time.Now()}
+}
+
+func (t *requestTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsDone = time.Now()
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil && t.connectDone.IsZero() {
+				t.connectDone = time.Now()
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsDone = time.Now()
+			if err == nil {
+				t.tlsState = &state
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.gotConn = time.Now()
+			t.reused = info.Reused
+			if info.Conn != nil {
+				t.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Now()
+		},
+	}
+}
+
+func (t *requestTracer) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bodyDone = time.Now()
+}
+
+func (t *requestTracer) timing() *ResponseTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing := &ResponseTiming{
+		DNSLookup:    phaseMillis(t.dnsStart, t.dnsDone),
+		TCPConnect:   phaseMillis(t.connectStart, t.connectDone),
+		TLSHandshake: phaseMillis(t.tlsStart, t.tlsDone),
+		Total:        phaseMillis(t.start, t.bodyDone),
+	}
+
+	if !t.firstByte.IsZero() {
+		requestSent := t.gotConn
+		if requestSent.IsZero() {
+			requestSent = t.start
+		}
+		timing.FirstByte = phaseMillis(requestSent, t.firstByte)
+		timing.Download = phaseMillis(t.firstByte, t.bodyDone)
+	}
+
+	return timing
+}
+
+func (t *requestTracer) connectionInfo(proto string, state *tls.ConnectionState) *ConnectionInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info := &ConnectionInfo{
+		RemoteAddr: t.remoteAddr,
+		Protocol:   proto,
+		Reused:     t.reused,
+	}
+
+	if state == nil {
+		state = t.tlsState
+	}
+	if state != nil {
+		info.TLSVersion = tls.VersionName(state.Version)
+		info.TLSCipher = tls.CipherSuiteName(state.CipherSuite)
+		if state.NegotiatedProtocol == "h2" {
+			info.Protocol = "HTTP/2.0"
+		}
+	}
+
+	return info
+}
+
+func phaseMillis(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return float64(end.Sub(start).Microseconds()) / 1000
+}
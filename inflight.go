@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+type inFlightRequest struct {
+	requestId string
+	cancel    context.CancelFunc
+}
+
+// inFlightRequests tracks the cancel functions of sends that are still running,
+// keyed by their execution ID.
+type inFlightRequests struct {
+	mu       sync.Mutex
+	requests map[string]inFlightRequest
+}
+
+func newInFlightRequests() *inFlightRequests {
+	return &inFlightRequests{
+		requests: make(map[string]inFlightRequest),
+	}
+}
+
+func (r *inFlightRequests) start(parent context.Context, id string, requestId string) (context.Context, func()) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	r.mu.Lock()
+	r.requests[id] = inFlightRequest{requestId: requestId, cancel: cancel}
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.requests, id)
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel aborts the send with the given execution ID. The ID of the saved
+// request is accepted as well so a tab can cancel without knowing the execution ID.
+func (r *inFlightRequests) cancel(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req, ok := r.requests[id]; ok {
+		req.cancel()
+		return true
+	}
+
+	cancelled := false
+	for _, req := range r.requests {
+		if id != "" && req.requestId == id {
+			req.cancel()
+			cancelled = true
+		}
+	}
+	return cancelled
+}
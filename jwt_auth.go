@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	JWTHS256 = "HS256"
+	JWTRS256 = "RS256"
+	JWTES256 = "ES256"
+)
+
+// jwtTimeClaims may be written relative to the signing time, e.g. "now", "+1h" or "-5m".
+var jwtTimeClaims = []string{"exp", "iat", "nbf"}
+
+// signJWT builds and signs a token from the auth templates. Every call yields a
+// fresh token so relative time claims are evaluated per request.
+func signJWT(auth *Auth, now time.Time) (string, error) {
+	algorithm := strings.ToUpper(strings.TrimSpace(auth.JwtAlgorithm))
+	if algorithm == "" {
+		algorithm = JWTHS256
+	}
+
+	header := map[string]interface{}{}
+	if err := parseJWTTemplate(auth.JwtHeader, &header); err != nil {
+		return "", fmt.Errorf("JWT 头部格式错误: %v", err)
+	}
+	header["alg"] = algorithm
+	if _, ok := header["typ"]; !ok {
+		header["typ"] = "JWT"
+	}
+
+	payload := map[string]interface{}{}
+	if err := parseJWTTemplate(auth.JwtPayload, &payload); err != nil {
+		return "", fmt.Errorf("JWT 载荷格式错误: %v", err)
+	}
+	for _, claim := range jwtTimeClaims {
+		value, ok := payload[claim].(string)
+		if !ok {
+			continue
+		}
+		seconds, err := resolveJWTTime(value, now)
+		if err != nil {
+			return "", fmt.Errorf("JWT 声明 %s 无效: %v", claim, err)
+		}
+		payload[claim] = seconds
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	signature, err := signJWTInput(algorithm, auth, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parseJWTTemplate(template string, v *map[string]interface{}) error {
+	if strings.TrimSpace(template) == "" {
+		return nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(template))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func resolveJWTTime(value string, now time.Time) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "now" {
+		return now.Unix(), nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	return now.Add(d).Unix(), nil
+}
+
+func signJWTInput(algorithm string, auth *Auth, input []byte) ([]byte, error) {
+	switch algorithm {
+	case JWTHS256:
+		if auth.JwtSecret == "" {
+			return nil, fmt.Errorf("JWT 密钥不能为空")
+		}
+		secret := []byte(auth.JwtSecret)
+		if auth.JwtSecretBase64 {
+			decoded, err := base64.StdEncoding.DecodeString(auth.JwtSecret)
+			if err != nil {
+				return nil, fmt.Errorf("JWT 密钥不是有效的 Base64: %v", err)
+			}
+			secret = decoded
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	case JWTRS256:
+		key, err := parsePrivateKey(auth.JwtPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("RS256 需要 RSA 私钥")
+		}
+		digest := sha256.Sum256(input)
+		return rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	case JWTES256:
+		key, err := parsePrivateKey(auth.JwtPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ES256 需要 P-256 椭圆曲线私钥")
+		}
+		digest := sha256.Sum256(input)
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// JWS wants the fixed-size r||s form rather than ASN.1.
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature, nil
+	default:
+		return nil, fmt.Errorf("不支持的 JWT 算法: %s", algorithm)
+	}
+}
+
+// parsePrivateKey reads a PEM encoded PKCS#8, PKCS#1 or SEC 1 private key.
+func parsePrivateKey(pemData string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(pemData)))
+	if block == nil {
+		return nil, fmt.Errorf("私钥不是有效的 PEM 格式")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("不支持的私钥类型")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("无法解析私钥")
+}
+
+// applyJWT signs a token and sends it as a bearer token or in a custom header.
+func applyJWT(httpReq *http.Request, auth *Auth) error {
+	token, err := signJWT(auth, time.Now())
+	if err != nil {
+		return err
+	}
+	if auth.JwtHeaderName == "" || strings.EqualFold(auth.JwtHeaderName, "Authorization") {
+		prefix := auth.JwtTokenPrefix
+		if prefix == "" {
+			prefix = "Bearer"
+		}
+		httpReq.Header.Set("Authorization", prefix+" "+token)
+		return nil
+	}
+	if auth.JwtTokenPrefix != "" {
+		token = auth.JwtTokenPrefix + " " + token
+	}
+	httpReq.Header.Set(auth.JwtHeaderName, token)
+	return nil
+}
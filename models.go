@@ -15,11 +15,22 @@ const (
 )
 
 type KeyValue struct {
-	Key     string `json:"key"`
-	Value   string `json:"value"`
-	Enabled bool   `json:"enabled"`
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Enabled     bool   `json:"enabled"`
+	Type        string `json:"type,omitempty"` // "text" (default) or "file" for form-data fields
+	FilePath    string `json:"filePath,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
+type FormFieldType string
+
+const (
+	FormFieldText FormFieldType = "text"
+	FormFieldFile FormFieldType = "file"
+)
+
 type BodyType string
 
 const (
@@ -33,9 +44,12 @@ const (
 )
 
 type RequestBody struct {
-	Type       string     `json:"type"`
-	Content    string     `json:"content,omitempty"`
-	FormData   []KeyValue `json:"formData,omitempty"`
+	Type        string     `json:"type"`
+	Content     string     `json:"content,omitempty"`
+	FormData    []KeyValue `json:"formData,omitempty"`
+	FilePath    string     `json:"filePath,omitempty"`    // binary body source, streamed from disk at send time
+	ContentType string     `json:"contentType,omitempty"` // overrides the detected binary content type
+	Compression string     `json:"compression,omitempty"` // "gzip", "deflate" or "zstd"; sets Content-Encoding
 }
 
 type AuthType string
@@ -45,6 +59,20 @@ const (
 	AuthBasic  AuthType = "basic"
 	AuthBearer AuthType = "bearer"
 	AuthOAuth2 AuthType = "oauth2"
+	AuthDigest AuthType = "digest"
+	AuthNTLM   AuthType = "ntlm"
+	AuthAWSV4  AuthType = "awsv4"
+	AuthHawk   AuthType = "hawk"
+	AuthAPIKey AuthType = "apikey"
+	AuthJWT    AuthType = "jwt"
+
+	// AuthInherit uses the auth of the parent level (the project).
+	AuthInherit AuthType = "inherit"
+)
+
+const (
+	AuthSourceRequest = "request"
+	AuthSourceProject = "project"
 )
 
 type Auth struct {
@@ -54,7 +82,7 @@ type Auth struct {
 	Token    string   `json:"token,omitempty"`
 	
 	// OAuth 2.0 fields
-	OAuth2GrantType  string `json:"oauth2GrantType,omitempty"`  // "authorization_code", "client_credentials", "password"
+	OAuth2GrantType  string `json:"oauth2GrantType,omitempty"`  // "authorization_code", "client_credentials", "password", "implicit", "device_code", "token_exchange", "jwt_bearer"
 	OAuth2AuthUrl    string `json:"oauth2AuthUrl,omitempty"`
 	OAuth2TokenUrl   string `json:"oauth2TokenUrl,omitempty"`
 	OAuth2ClientId   string `json:"oauth2ClientId,omitempty"`
@@ -63,6 +91,70 @@ type Auth struct {
 	OAuth2RedirectUrl string `json:"oauth2RedirectUrl,omitempty"`
 	OAuth2AccessToken string `json:"oauth2AccessToken,omitempty"`
 	OAuth2RefreshToken string `json:"oauth2RefreshToken,omitempty"`
+
+	OAuth2TokenType string `json:"oauth2TokenType,omitempty"`
+	OAuth2ExpiresAt int64  `json:"oauth2ExpiresAt,omitempty"` // unix seconds, 0 when unknown
+
+	// OpenID Connect: endpoints are discovered from the issuer when left empty
+	OAuth2Issuer        string                 `json:"oauth2Issuer,omitempty"`
+	OAuth2IdToken       string                 `json:"oauth2IdToken,omitempty"`
+	OAuth2IdTokenClaims map[string]interface{} `json:"oauth2IdTokenClaims,omitempty"` // verified claims of OAuth2IdToken
+
+	// Token endpoint client authentication and extra request parameters
+	OAuth2ClientAuthMethod string     `json:"oauth2ClientAuthMethod,omitempty"` // client_secret_post (default), client_secret_basic, client_secret_jwt, private_key_jwt or none
+	OAuth2ClientPrivateKey string     `json:"oauth2ClientPrivateKey,omitempty"` // PEM, for private_key_jwt
+	OAuth2ClientKeyId      string     `json:"oauth2ClientKeyId,omitempty"`
+	OAuth2ExtraParams      []KeyValue `json:"oauth2ExtraParams,omitempty"`
+	OAuth2ExtraHeaders     []KeyValue `json:"oauth2ExtraHeaders,omitempty"`
+
+	// Resource owner password grant; the password itself is never stored
+	OAuth2Username string `json:"oauth2Username,omitempty"`
+
+	// Device authorization grant (RFC 8628)
+	OAuth2DeviceAuthUrl string `json:"oauth2DeviceAuthUrl,omitempty"`
+
+	// Token exchange (RFC 8693)
+	OAuth2SubjectToken       string `json:"oauth2SubjectToken,omitempty"`
+	OAuth2SubjectTokenType   string `json:"oauth2SubjectTokenType,omitempty"` // defaults to an access token
+	OAuth2ActorToken         string `json:"oauth2ActorToken,omitempty"`
+	OAuth2ActorTokenType     string `json:"oauth2ActorTokenType,omitempty"`
+	OAuth2RequestedTokenType string `json:"oauth2RequestedTokenType,omitempty"`
+	OAuth2Audience           string `json:"oauth2Audience,omitempty"`
+	OAuth2Resource           string `json:"oauth2Resource,omitempty"`
+
+	// JWT bearer assertion (RFC 7523); signed from the JWT fields below when empty
+	OAuth2Assertion string `json:"oauth2Assertion,omitempty"`
+
+	// NTLM (Username/Password above)
+	Domain string `json:"domain,omitempty"`
+
+	// AWS Signature V4
+	AwsAccessKey    string `json:"awsAccessKey,omitempty"`
+	AwsSecretKey    string `json:"awsSecretKey,omitempty"`
+	AwsSessionToken string `json:"awsSessionToken,omitempty"`
+	AwsRegion       string `json:"awsRegion,omitempty"`
+	AwsService      string `json:"awsService,omitempty"`
+
+	// Hawk
+	HawkId        string `json:"hawkId,omitempty"`
+	HawkKey       string `json:"hawkKey,omitempty"`
+	HawkAlgorithm string `json:"hawkAlgorithm,omitempty"` // "sha256" or "sha1"
+	HawkExt       string `json:"hawkExt,omitempty"`
+
+	// API key
+	ApiKeyName  string `json:"apiKeyName,omitempty"`
+	ApiKeyValue string `json:"apiKeyValue,omitempty"`
+	ApiKeyIn    string `json:"apiKeyIn,omitempty"` // "header", "query" or "cookie"
+
+	// JWT signed locally for every request
+	JwtAlgorithm    string `json:"jwtAlgorithm,omitempty"` // HS256, RS256 or ES256
+	JwtSecret       string `json:"jwtSecret,omitempty"`
+	JwtSecretBase64 bool   `json:"jwtSecretBase64,omitempty"`
+	JwtPrivateKey   string `json:"jwtPrivateKey,omitempty"` // PEM
+	JwtHeader       string `json:"jwtHeader,omitempty"`     // JSON template
+	JwtPayload      string `json:"jwtPayload,omitempty"`    // JSON template, exp/iat/nbf may be "now" or "+1h"
+	JwtHeaderName   string `json:"jwtHeaderName,omitempty"` // defaults to Authorization
+	JwtTokenPrefix  string `json:"jwtTokenPrefix,omitempty"`
 }
 
 type Scripts struct {
@@ -70,17 +162,35 @@ type Scripts struct {
 	PostRequest string `json:"postRequest,omitempty"`
 }
 
+type RequestSettings struct {
+	AcceptEncoding string `json:"acceptEncoding,omitempty"` // sent when no Accept-Encoding header is set; defaults to gzip, deflate, br, zstd
+	RawResponse    bool   `json:"rawResponse,omitempty"`    // keep the encoded bytes instead of decompressing them
+	Protocol       string `json:"protocol,omitempty"`       // "auto", "http1", "http2", "h2c" or "http3"
+
+	Retry *RetryPolicy `json:"retry,omitempty"` // falls back to the project's policy when nil
+}
+
 type HttpRequest struct {
-	ID        string       `json:"id"`
-	Name      string       `json:"name"`
-	Method    HttpMethod   `json:"method"`
-	URL       string       `json:"url"`
-	Headers   []KeyValue   `json:"headers"`
-	Params    []KeyValue   `json:"params"`
-	Body      *RequestBody `json:"body,omitempty"`
-	Auth      *Auth        `json:"auth,omitempty"`
-	Scripts   *Scripts     `json:"scripts,omitempty"`
-	ProjectId string       `json:"projectId,omitempty"`
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Method     HttpMethod       `json:"method"`
+	URL        string           `json:"url"`
+	Headers    []KeyValue       `json:"headers"`
+	Params     []KeyValue       `json:"params"`
+	PathParams []KeyValue       `json:"pathParams,omitempty"` // values for :name and {name} segments in URL
+	Body       *RequestBody     `json:"body,omitempty"`
+	Auth       *Auth            `json:"auth,omitempty"`
+	Scripts    *Scripts         `json:"scripts,omitempty"`
+	Settings   *RequestSettings `json:"settings,omitempty"`
+	ProjectId  string           `json:"projectId,omitempty"`
+	OpenAPI    *OpenAPIOrigin   `json:"openapi,omitempty"` // set on requests imported from an OpenAPI spec
+}
+
+// OpenAPIOrigin links an imported request to its operation so re-imports can
+// update it in place. Signature is what the spec last generated for it.
+type OpenAPIOrigin struct {
+	OperationKey string   `json:"operationKey"` // operationId, or "METHOD /path" when there is none
+	Signature    []string `json:"signature,omitempty"`
 }
 
 type TestResult struct {
@@ -96,13 +206,37 @@ type ScriptResult struct {
 }
 
 type HttpResponse struct {
-	Status       int               `json:"status"`
-	StatusText   string            `json:"statusText"`
-	Headers      map[string]string `json:"headers"`
-	Body         string            `json:"body"`
-	Size         int64             `json:"size"`
-	Time         int64             `json:"time"`
-	ScriptResult *ScriptResult     `json:"scriptResult,omitempty"`
+	ID              string           `json:"id,omitempty"`
+	Status          int              `json:"status"`
+	StatusText      string           `json:"statusText"`
+	Headers         []KeyValue       `json:"headers"`
+	Body            string           `json:"body"`
+	BodyFile        string           `json:"bodyFile,omitempty"`     // full body on disk when it was too large to keep in memory
+	Truncated       bool             `json:"truncated,omitempty"`    // Body only holds a preview
+	BodyEncoding    string           `json:"bodyEncoding,omitempty"` // "text" or "base64"
+	IsBinary        bool             `json:"isBinary,omitempty"`
+	ContentType     string           `json:"contentType,omitempty"`
+	Charset         string           `json:"charset,omitempty"`
+	Preview         *ResponsePreview `json:"preview,omitempty"`
+	Size            int64            `json:"size"`
+	CompressedSize  int64            `json:"compressedSize,omitempty"` // bytes received on the wire when Content-Encoding was set
+	ContentEncoding string           `json:"contentEncoding,omitempty"`
+	Decompressed    bool             `json:"decompressed,omitempty"`
+	Time            int64            `json:"time"`
+	Timing          *ResponseTiming  `json:"timing,omitempty"`
+	Connection      *ConnectionInfo  `json:"connection,omitempty"`
+	ScriptResult    *ScriptResult    `json:"scriptResult,omitempty"`
+
+	// Sizes of the request body before and after compression, set when RequestBody.Compression is used.
+	RequestBodySize       int64 `json:"requestBodySize,omitempty"`
+	RequestCompressedSize int64 `json:"requestCompressedSize,omitempty"`
+
+	Attempts []RetryAttempt `json:"attempts,omitempty"` // one entry per attempt when a retry policy applied
+	Error    string         `json:"error,omitempty"`    // why the last attempt failed, for history records of failed retries
+
+	AuthSource string `json:"authSource,omitempty"` // level that supplied the auth: "request" or "project"
+
+	rawBody []byte
 }
 
 type HistoryRecord struct {
@@ -113,19 +247,30 @@ type HistoryRecord struct {
 }
 
 type Project struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	BaseUrl     string    `json:"baseUrl,omitempty"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	BaseUrl     string       `json:"baseUrl,omitempty"`
+	Retry       *RetryPolicy `json:"retry,omitempty"`
+	Auth        *Auth        `json:"auth,omitempty"` // used by requests whose auth is inherit
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
 }
 
 type Token struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
 	Value     string    `json:"value"`
-	HeaderKey string    `json:"headerKey"`
+	HeaderKey string    `json:"headerKey"` // name of the header, query param or cookie
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Automatic application: the token is added to every request matching all
+	// of the non-empty bindings below.
+	AutoApply     bool   `json:"autoApply,omitempty"`
+	ProjectId     string `json:"projectId,omitempty"`
+	EnvironmentId string `json:"environmentId,omitempty"`
+	UrlPattern    string `json:"urlPattern,omitempty"` // * matches any characters, e.g. https://api.example.com/*
+	In            string `json:"in,omitempty"`         // "header" (default), "query" or "cookie"
+	Prefix        string `json:"prefix,omitempty"`     // e.g. "Bearer "
 }
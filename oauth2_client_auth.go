@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Client authentication methods for the token endpoint, named as in the
+// OpenID Connect registry.
+const (
+	ClientAuthSecretPost  = "client_secret_post" // default
+	ClientAuthSecretBasic = "client_secret_basic"
+	ClientAuthSecretJWT   = "client_secret_jwt"
+	ClientAuthPrivateKey  = "private_key_jwt"
+	ClientAuthNone        = "none"
+
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+// OAuth2Error is an RFC 6749 section 5.2 error response.
+type OAuth2Error struct {
+	StatusCode  int    `json:"statusCode"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	URI         string `json:"error_uri,omitempty"`
+}
+
+func (e *OAuth2Error) Error() string {
+	msg := fmt.Sprintf("令牌请求失败 (状态码 %d): %s", e.StatusCode, e.Code)
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	if e.URI != "" {
+		msg += " (" + e.URI + ")"
+	}
+	return msg
+}
+
+// parseOAuth2Error returns an *OAuth2Error when the body is a standard error
+// response, and a plain error carrying the body otherwise.
+func parseOAuth2Error(status int, body []byte) error {
+	oauthErr := &OAuth2Error{StatusCode: status}
+	if err := json.Unmarshal(body, oauthErr); err == nil && oauthErr.Code != "" {
+		oauthErr.StatusCode = status
+		return oauthErr
+	}
+	return fmt.Errorf("令牌请求失败 (状态码 %d): %s", status, string(body))
+}
+
+// applyClientAuth authenticates the client on a token endpoint call and adds
+// the extra parameters and headers configured on auth.
+func applyClientAuth(auth *Auth, endpoint string, data url.Values, header http.Header) error {
+	for _, param := range auth.OAuth2ExtraParams {
+		if param.Enabled && param.Key != "" {
+			data.Set(param.Key, param.Value)
+		}
+	}
+	for _, h := range auth.OAuth2ExtraHeaders {
+		if h.Enabled && h.Key != "" {
+			header.Set(h.Key, h.Value)
+		}
+	}
+
+	if auth.OAuth2ClientId == "" {
+		return nil
+	}
+
+	switch auth.OAuth2ClientAuthMethod {
+	case "", ClientAuthSecretPost:
+		data.Set("client_id", auth.OAuth2ClientId)
+		if auth.OAuth2ClientSecret != "" {
+			data.Set("client_secret", auth.OAuth2ClientSecret)
+		}
+	case ClientAuthSecretBasic:
+		// RFC 6749 section 2.3.1: both parts are form encoded before base64.
+		credentials := url.QueryEscape(auth.OAuth2ClientId) + ":" + url.QueryEscape(auth.OAuth2ClientSecret)
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	case ClientAuthSecretJWT, ClientAuthPrivateKey:
+		assertion, err := clientAssertion(auth, endpoint)
+		if err != nil {
+			return err
+		}
+		data.Set("client_id", auth.OAuth2ClientId)
+		data.Set("client_assertion_type", clientAssertionType)
+		data.Set("client_assertion", assertion)
+	case ClientAuthNone:
+		data.Set("client_id", auth.OAuth2ClientId)
+	default:
+		return fmt.Errorf("不支持的客户端认证方式: %s", auth.OAuth2ClientAuthMethod)
+	}
+	return nil
+}
+
+// clientAssertion signs the short lived JWT of RFC 7523 section 2.2 with the
+// client secret (HS256) or the client private key (RS256 or ES256).
+func clientAssertion(auth *Auth, audience string) (string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"iss": auth.OAuth2ClientId,
+		"sub": auth.OAuth2ClientId,
+		"aud": audience,
+		"jti": randomURLToken(16),
+		"iat": "now",
+		"exp": "+5m",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	jwtAuth := &Auth{JwtPayload: string(payload)}
+	if auth.OAuth2ClientAuthMethod == ClientAuthSecretJWT {
+		if auth.OAuth2ClientSecret == "" {
+			return "", fmt.Errorf("client_secret_jwt 需要客户端密钥")
+		}
+		jwtAuth.JwtAlgorithm = JWTHS256
+		jwtAuth.JwtSecret = auth.OAuth2ClientSecret
+	} else {
+		key, err := parsePrivateKey(auth.OAuth2ClientPrivateKey)
+		if err != nil {
+			return "", err
+		}
+		switch key.(type) {
+		case *rsa.PrivateKey:
+			jwtAuth.JwtAlgorithm = JWTRS256
+		case *ecdsa.PrivateKey:
+			jwtAuth.JwtAlgorithm = JWTES256
+		default:
+			return "", fmt.Errorf("private_key_jwt 只支持 RSA 或 P-256 私钥")
+		}
+		jwtAuth.JwtPrivateKey = auth.OAuth2ClientPrivateKey
+		if auth.OAuth2ClientKeyId != "" {
+			header, _ := json.Marshal(map[string]string{"kid": auth.OAuth2ClientKeyId})
+			jwtAuth.JwtHeader = string(header)
+		}
+	}
+
+	return signJWT(jwtAuth, time.Now())
+}
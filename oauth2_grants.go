@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	grantTypeDeviceCode    = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	grantTypeJWTBearer     = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	tokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// OAuth2DeviceCodeEvent is emitted as oauth2:device_code so the UI can show the
+// user code. ID can be passed to CancelRequest to stop polling.
+type OAuth2DeviceCodeEvent struct {
+	ID                      string `json:"id"`
+	UserCode                string `json:"userCode"`
+	VerificationUri         string `json:"verificationUri"`
+	VerificationUriComplete string `json:"verificationUriComplete,omitempty"`
+	ExpiresIn               int    `json:"expiresIn"`
+}
+
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationUri         string `json:"verification_uri"`
+	VerificationUriComplete string `json:"verification_uri_complete,omitempty"`
+	VerificationUrl         string `json:"verification_url,omitempty"` // used by some providers instead of verification_uri
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+func (h *OAuth2Handler) RequestDeviceCode(auth *Auth) (*deviceAuthorizationResponse, error) {
+	if auth.OAuth2DeviceAuthUrl == "" || auth.OAuth2ClientId == "" {
+		return nil, fmt.Errorf("设备授权URL和客户端ID不能为空")
+	}
+
+	data := url.Values{}
+	if auth.OAuth2Scope != "" {
+		data.Set("scope", auth.OAuth2Scope)
+	}
+
+	status, body, err := h.postForm(auth, auth.OAuth2DeviceAuthUrl, data)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, parseOAuth2Error(status, body)
+	}
+
+	var deviceResp deviceAuthorizationResponse
+	if err := json.Unmarshal(body, &deviceResp); err != nil {
+		return nil, fmt.Errorf("解析设备授权响应失败: %w", err)
+	}
+	if deviceResp.VerificationUri == "" {
+		deviceResp.VerificationUri = deviceResp.VerificationUrl
+	}
+	if deviceResp.DeviceCode == "" || deviceResp.UserCode == "" {
+		return nil, fmt.Errorf("设备授权响应缺少 device_code 或 user_code")
+	}
+
+	return &deviceResp, nil
+}
+
+// PollDeviceToken polls the token endpoint until the user approves or denies
+// the device, the code expires or ctx is cancelled.
+func (h *OAuth2Handler) PollDeviceToken(ctx context.Context, auth *Auth, deviceResp *deviceAuthorizationResponse) (*OAuth2TokenResponse, error) {
+	if auth.OAuth2TokenUrl == "" {
+		return nil, fmt.Errorf("令牌URL不能为空")
+	}
+
+	interval := time.Duration(deviceResp.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	if deviceResp.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(deviceResp.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", grantTypeDeviceCode)
+	data.Set("device_code", deviceResp.DeviceCode)
+
+	for {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, fmt.Errorf("设备码已过期")
+			}
+			return nil, fmt.Errorf("授权已取消")
+		case <-time.After(interval):
+		}
+
+		// Copy so client assertions are signed afresh on every poll.
+		pollData := url.Values{}
+		for key, values := range data {
+			pollData[key] = values
+		}
+		status, body, err := h.postForm(auth, auth.OAuth2TokenUrl, pollData)
+		if err != nil {
+			return nil, err
+		}
+
+		if status == http.StatusOK {
+			var tokenResp OAuth2TokenResponse
+			if err := json.Unmarshal(body, &tokenResp); err != nil {
+				return nil, fmt.Errorf("解析令牌响应失败: %w", err)
+			}
+			return &tokenResp, nil
+		}
+
+		err = parseOAuth2Error(status, body)
+		oauthErr, ok := err.(*OAuth2Error)
+		if !ok {
+			return nil, err
+		}
+		switch oauthErr.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return nil, fmt.Errorf("用户拒绝了授权")
+		case "expired_token":
+			return nil, fmt.Errorf("设备码已过期")
+		default:
+			return nil, oauthErr
+		}
+	}
+}
+
+func (h *OAuth2Handler) GetTokenExchangeToken(auth *Auth) (*OAuth2TokenResponse, error) {
+	if auth.OAuth2TokenUrl == "" || auth.OAuth2SubjectToken == "" {
+		return nil, fmt.Errorf("令牌URL和 subject_token 不能为空")
+	}
+
+	subjectTokenType := auth.OAuth2SubjectTokenType
+	if subjectTokenType == "" {
+		subjectTokenType = tokenTypeAccessToken
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", grantTypeTokenExchange)
+	data.Set("subject_token", auth.OAuth2SubjectToken)
+	data.Set("subject_token_type", subjectTokenType)
+	if auth.OAuth2ActorToken != "" {
+		actorTokenType := auth.OAuth2ActorTokenType
+		if actorTokenType == "" {
+			actorTokenType = tokenTypeAccessToken
+		}
+		data.Set("actor_token", auth.OAuth2ActorToken)
+		data.Set("actor_token_type", actorTokenType)
+	}
+	if auth.OAuth2RequestedTokenType != "" {
+		data.Set("requested_token_type", auth.OAuth2RequestedTokenType)
+	}
+	if auth.OAuth2Audience != "" {
+		data.Set("audience", auth.OAuth2Audience)
+	}
+	if auth.OAuth2Resource != "" {
+		data.Set("resource", auth.OAuth2Resource)
+	}
+	if auth.OAuth2Scope != "" {
+		data.Set("scope", auth.OAuth2Scope)
+	}
+
+	return h.requestToken(auth, data)
+}
+
+// GetJwtBearerToken uses OAuth2Assertion, or a JWT signed from the auth's JWT
+// fields when no assertion is given.
+func (h *OAuth2Handler) GetJwtBearerToken(auth *Auth) (*OAuth2TokenResponse, error) {
+	if auth.OAuth2TokenUrl == "" {
+		return nil, fmt.Errorf("令牌URL不能为空")
+	}
+
+	assertion := auth.OAuth2Assertion
+	if assertion == "" {
+		var err error
+		assertion, err = signJWT(auth, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", grantTypeJWTBearer)
+	data.Set("assertion", assertion)
+	if auth.OAuth2Scope != "" {
+		data.Set("scope", auth.OAuth2Scope)
+	}
+
+	return h.requestToken(auth, data)
+}
+
+// StartOAuth2DeviceFlow requests a device code, emits the user code for the UI,
+// opens the verification page and polls until the token is issued.
+func (a *App) StartOAuth2DeviceFlow(saved Auth) (Auth, error) {
+	auth := a.substituteAuthVariables(saved)
+	handler := NewOAuth2Handler(a)
+	if _, err := handler.discover(&auth); err != nil {
+		return saved, err
+	}
+
+	deviceResp, err := handler.RequestDeviceCode(&auth)
+	if err != nil {
+		return saved, err
+	}
+
+	id := randomURLToken(16)
+	ctx, done := a.inFlight.start(a.ctx, id, "")
+	defer done()
+
+	a.emitEvent("oauth2:device_code", OAuth2DeviceCodeEvent{
+		ID:                      id,
+		UserCode:                deviceResp.UserCode,
+		VerificationUri:         deviceResp.VerificationUri,
+		VerificationUriComplete: deviceResp.VerificationUriComplete,
+		ExpiresIn:               deviceResp.ExpiresIn,
+	})
+	if a.ctx != nil {
+		verificationUri := deviceResp.VerificationUriComplete
+		if verificationUri == "" {
+			verificationUri = deviceResp.VerificationUri
+		}
+		runtime.BrowserOpenURL(a.ctx, verificationUri)
+	}
+
+	tokenResp, err := handler.PollDeviceToken(ctx, &auth, deviceResp)
+	if err != nil {
+		return saved, err
+	}
+
+	if err := a.cacheOAuth2Token(&auth, tokenResp, ""); err != nil {
+		return saved, err
+	}
+
+	return oauth2Result(saved, auth), nil
+}
+
+func (a *App) GetOAuth2TokenExchangeToken(saved Auth) (Auth, error) {
+	auth := a.substituteAuthVariables(saved)
+	handler := NewOAuth2Handler(a)
+	if _, err := handler.discover(&auth); err != nil {
+		return saved, err
+	}
+
+	tokenResp, err := handler.GetTokenExchangeToken(&auth)
+	if err != nil {
+		return saved, err
+	}
+
+	if err := a.cacheOAuth2Token(&auth, tokenResp, ""); err != nil {
+		return saved, err
+	}
+
+	return oauth2Result(saved, auth), nil
+}
+
+func (a *App) GetOAuth2JwtBearerToken(saved Auth) (Auth, error) {
+	auth := a.substituteAuthVariables(saved)
+	handler := NewOAuth2Handler(a)
+	if _, err := handler.discover(&auth); err != nil {
+		return saved, err
+	}
+
+	tokenResp, err := handler.GetJwtBearerToken(&auth)
+	if err != nil {
+		return saved, err
+	}
+
+	if err := a.cacheOAuth2Token(&auth, tokenResp, ""); err != nil {
+		return saved, err
+	}
+
+	return oauth2Result(saved, auth), nil
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,9 +28,12 @@ type OAuth2TokenResponse struct {
 	ExpiresIn    int    `json:"expires_in"`
 	RefreshToken string `json:"refresh_token,omitempty"`
 	Scope        string `json:"scope,omitempty"`
+
+	IssuedTokenType string `json:"issued_token_type,omitempty"` // token exchange (RFC 8693)
+	IdToken         string `json:"id_token,omitempty"`          // OpenID Connect
 }
 
-func (h *OAuth2Handler) GetAuthorizationUrl(auth *Auth) (string, error) {
+func (h *OAuth2Handler) GetAuthorizationUrl(auth *Auth, state string, codeChallenge string, nonce string) (string, error) {
 	if auth.OAuth2AuthUrl == "" || auth.OAuth2ClientId == "" {
 		return "", fmt.Errorf("授权URL和客户端ID不能为空")
 	}
@@ -41,8 +45,12 @@ func (h *OAuth2Handler) GetAuthorizationUrl(auth *Auth) (string, error) {
 
 	params := url.Values{}
 	params.Add("client_id", auth.OAuth2ClientId)
-	params.Add("response_type", "code")
-	
+	if auth.OAuth2GrantType == "implicit" {
+		params.Add("response_type", "token")
+	} else {
+		params.Add("response_type", "code")
+	}
+
 	if auth.OAuth2RedirectUrl != "" {
 		params.Add("redirect_uri", auth.OAuth2RedirectUrl)
 	}
@@ -51,13 +59,22 @@ func (h *OAuth2Handler) GetAuthorizationUrl(auth *Auth) (string, error) {
 		params.Add("scope", auth.OAuth2Scope)
 	}
 	
-	params.Add("state", "postgo_oauth2_state")
-	
+	params.Add("state", state)
+
+	if codeChallenge != "" {
+		params.Add("code_challenge", codeChallenge)
+		params.Add("code_challenge_method", "S256")
+	}
+
+	if nonce != "" {
+		params.Add("nonce", nonce)
+	}
+
 	authUrl.RawQuery = params.Encode()
 	return authUrl.String(), nil
 }
 
-func (h *OAuth2Handler) ExchangeCodeForToken(auth *Auth, code string) (*OAuth2TokenResponse, error) {
+func (h *OAuth2Handler) ExchangeCodeForToken(auth *Auth, code string, codeVerifier string) (*OAuth2TokenResponse, error) {
 	if auth.OAuth2TokenUrl == "" {
 		return nil, fmt.Errorf("令牌URL不能为空")
 	}
@@ -65,14 +82,16 @@ func (h *OAuth2Handler) ExchangeCodeForToken(auth *Auth, code string) (*OAuth2To
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
 	data.Set("code", code)
-	data.Set("client_id", auth.OAuth2ClientId)
-	data.Set("client_secret", auth.OAuth2ClientSecret)
 	
 	if auth.OAuth2RedirectUrl != "" {
 		data.Set("redirect_uri", auth.OAuth2RedirectUrl)
 	}
 
-	return h.requestToken(auth.OAuth2TokenUrl, data)
+	if codeVerifier != "" {
+		data.Set("code_verifier", codeVerifier)
+	}
+
+	return h.requestToken(auth, data)
 }
 
 func (h *OAuth2Handler) GetClientCredentialsToken(auth *Auth) (*OAuth2TokenResponse, error) {
@@ -82,14 +101,12 @@ func (h *OAuth2Handler) GetClientCredentialsToken(auth *Auth) (*OAuth2TokenRespo
 
 	data := url.Values{}
 	data.Set("grant_type", "client_credentials")
-	data.Set("client_id", auth.OAuth2ClientId)
-	data.Set("client_secret", auth.OAuth2ClientSecret)
 	
 	if auth.OAuth2Scope != "" {
 		data.Set("scope", auth.OAuth2Scope)
 	}
 
-	return h.requestToken(auth.OAuth2TokenUrl, data)
+	return h.requestToken(auth, data)
 }
 
 func (h *OAuth2Handler) GetPasswordToken(auth *Auth, username, password string) (*OAuth2TokenResponse, error) {
@@ -101,14 +118,12 @@ func (h *OAuth2Handler) GetPasswordToken(auth *Auth, username, password string)
 	data.Set("grant_type", "password")
 	data.Set("username", username)
 	data.Set("password", password)
-	data.Set("client_id", auth.OAuth2ClientId)
-	data.Set("client_secret", auth.OAuth2ClientSecret)
 	
 	if auth.OAuth2Scope != "" {
 		data.Set("scope", auth.OAuth2Scope)
 	}
 
-	return h.requestToken(auth.OAuth2TokenUrl, data)
+	return h.requestToken(auth, data)
 }
 
 func (h *OAuth2Handler) RefreshToken(auth *Auth) (*OAuth2TokenResponse, error) {
@@ -119,23 +134,49 @@ func (h *OAuth2Handler) RefreshToken(auth *Auth) (*OAuth2TokenResponse, error) {
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
 	data.Set("refresh_token", auth.OAuth2RefreshToken)
-	data.Set("client_id", auth.OAuth2ClientId)
-	data.Set("client_secret", auth.OAuth2ClientSecret)
 
-	return h.requestToken(auth.OAuth2TokenUrl, data)
+	return h.requestToken(auth, data)
 }
 
-func (h *OAuth2Handler) requestToken(tokenUrl string, data url.Values) (*OAuth2TokenResponse, error) {
+func (h *OAuth2Handler) requestToken(auth *Auth, data url.Values) (*OAuth2TokenResponse, error) {
+	status, body, err := h.postForm(auth, auth.OAuth2TokenUrl, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, parseOAuth2Error(status, body)
+	}
+
+	var tokenResp OAuth2TokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, fmt.Errorf("解析令牌响应失败: %w", err)
+	}
+
+	return &tokenResp, nil
+}
+
+// postForm sends a form encoded POST with client authentication to an OAuth2
+// endpoint and returns the raw response.
+func (h *OAuth2Handler) postForm(auth *Auth, endpoint string, data url.Values) (int, []byte, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", tokenUrl, strings.NewReader(data.Encode()))
+	header := http.Header{}
+	if err := applyClientAuth(auth, endpoint, data, header); err != nil {
+		return 0, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(data.Encode()))
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return 0, nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 
+	req.Header = header
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Accept", "application/json")
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
 
 	client := &http.Client{
 		Timeout: 30 * time.Second,
@@ -143,93 +184,172 @@ func (h *OAuth2Handler) requestToken(tokenUrl string, data url.Values) (*OAuth2T
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("请求令牌失败: %w", err)
+		return 0, nil, fmt.Errorf("请求令牌失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		return 0, nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	return resp.StatusCode, body, nil
+}
+
+// StartOAuth2Flow runs the authorization code flow with PKCE, or the implicit
+// flow: it opens the browser, captures the redirect on a loopback listener and
+// exchanges the code.
+func (a *App) StartOAuth2Flow(saved Auth) (Auth, error) {
+	auth := a.substituteAuthVariables(saved)
+	handler := NewOAuth2Handler(a)
+	implicit := auth.OAuth2GrantType == "implicit"
+
+	oidcConfig, err := handler.discover(&auth)
+	if err != nil {
+		return saved, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("令牌请求失败 (状态码 %d): %s", resp.StatusCode, string(body))
+	state := randomURLToken(16)
+	verifier, challenge := "", ""
+	responseKey := "access_token"
+	if !implicit {
+		verifier, challenge = newPKCE()
+		responseKey = "code"
+	}
+	nonce := ""
+	if oidcConfig != nil {
+		nonce = randomURLToken(16)
 	}
 
-	var tokenResp OAuth2TokenResponse
-	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		return nil, fmt.Errorf("解析令牌响应失败: %w", err)
+	server, err := startLoopbackServer(auth.OAuth2RedirectUrl, state, responseKey)
+	if err != nil {
+		return saved, err
 	}
+	defer server.close()
 
-	return &tokenResp, nil
-}
+	// The redirect_uri sent to both endpoints must match the listener.
+	flowAuth := auth
+	flowAuth.OAuth2RedirectUrl = server.redirectUrl
 
-func (a *App) StartOAuth2Flow(auth Auth) error {
-	handler := NewOAuth2Handler(a)
-	
-	authUrl, err := handler.GetAuthorizationUrl(&auth)
+	authUrl, err := handler.GetAuthorizationUrl(&flowAuth, state, challenge, nonce)
 	if err != nil {
-		return err
+		return saved, err
 	}
 
-	runtime.BrowserOpenURL(a.ctx, authUrl)
-	return nil
+	ctx, done := a.inFlight.start(a.ctx, state, "")
+	defer done()
+	ctx, cancel := context.WithTimeout(ctx, oauth2CallbackTimeout)
+	defer cancel()
+
+	a.emitEvent("oauth2:started", OAuth2FlowEvent{ID: state, AuthUrl: authUrl, RedirectUrl: server.redirectUrl})
+	if a.ctx != nil {
+		runtime.BrowserOpenURL(a.ctx, authUrl)
+	}
+
+	params, err := server.wait(ctx)
+	if err != nil {
+		return saved, err
+	}
+
+	var tokenResp *OAuth2TokenResponse
+	if implicit {
+		expiresIn, _ := strconv.Atoi(params.Get("expires_in"))
+		tokenResp = &OAuth2TokenResponse{
+			AccessToken: params.Get("access_token"),
+			TokenType:   params.Get("token_type"),
+			ExpiresIn:   expiresIn,
+			Scope:       params.Get("scope"),
+			IdToken:     params.Get("id_token"),
+		}
+	} else {
+		tokenResp, err = handler.ExchangeCodeForToken(&flowAuth, params.Get("code"), verifier)
+		if err != nil {
+			return saved, err
+		}
+	}
+
+	if err := a.cacheOAuth2Token(&auth, tokenResp, nonce); err != nil {
+		return saved, err
+	}
+
+	return oauth2Result(saved, auth), nil
 }
 
-func (a *App) ExchangeOAuth2Code(auth Auth, code string) (Auth, error) {
+func (a *App) ExchangeOAuth2Code(saved Auth, code string) (Auth, error) {
+	auth := a.substituteAuthVariables(saved)
 	handler := NewOAuth2Handler(a)
+	if _, err := handler.discover(&auth); err != nil {
+		return saved, err
+	}
 	
-	tokenResp, err := handler.ExchangeCodeForToken(&auth, code)
+	tokenResp, err := handler.ExchangeCodeForToken(&auth, code, "")
 	if err != nil {
-		return auth, err
+		return saved, err
 	}
 
-	auth.OAuth2AccessToken = tokenResp.AccessToken
-	auth.OAuth2RefreshToken = tokenResp.RefreshToken
+	if err := a.cacheOAuth2Token(&auth, tokenResp, ""); err != nil {
+		return saved, err
+	}
 
-	return auth, nil
+	return oauth2Result(saved, auth), nil
 }
 
-func (a *App) GetOAuth2ClientCredentialsToken(auth Auth) (Auth, error) {
+func (a *App) GetOAuth2ClientCredentialsToken(saved Auth) (Auth, error) {
+	auth := a.substituteAuthVariables(saved)
 	handler := NewOAuth2Handler(a)
+	if _, err := handler.discover(&auth); err != nil {
+		return saved, err
+	}
 	
 	tokenResp, err := handler.GetClientCredentialsToken(&auth)
 	if err != nil {
-		return auth, err
+		return saved, err
 	}
 
-	auth.OAuth2AccessToken = tokenResp.AccessToken
-	auth.OAuth2RefreshToken = tokenResp.RefreshToken
+	if err := a.cacheOAuth2Token(&auth, tokenResp, ""); err != nil {
+		return saved, err
+	}
 
-	return auth, nil
+	return oauth2Result(saved, auth), nil
 }
 
-func (a *App) GetOAuth2PasswordToken(auth Auth, username, password string) (Auth, error) {
+func (a *App) GetOAuth2PasswordToken(saved Auth, username, password string) (Auth, error) {
+	// The username is kept so tokens of different resource owners are cached apart.
+	saved.OAuth2Username = username
+	auth := a.substituteAuthVariables(saved)
+	password = a.ReplaceVariables(password)
 	handler := NewOAuth2Handler(a)
+	if _, err := handler.discover(&auth); err != nil {
+		return saved, err
+	}
 	
-	tokenResp, err := handler.GetPasswordToken(&auth, username, password)
+	tokenResp, err := handler.GetPasswordToken(&auth, auth.OAuth2Username, password)
 	if err != nil {
-		return auth, err
+		return saved, err
 	}
 
-	auth.OAuth2AccessToken = tokenResp.AccessToken
-	auth.OAuth2RefreshToken = tokenResp.RefreshToken
+	if err := a.cacheOAuth2Token(&auth, tokenResp, ""); err != nil {
+		return saved, err
+	}
 
-	return auth, nil
+	return oauth2Result(saved, auth), nil
 }
 
-func (a *App) RefreshOAuth2Token(auth Auth) (Auth, error) {
+func (a *App) RefreshOAuth2Token(saved Auth) (Auth, error) {
+	auth := a.substituteAuthVariables(saved)
 	handler := NewOAuth2Handler(a)
+	if _, err := handler.discover(&auth); err != nil {
+		return saved, err
+	}
 	
 	tokenResp, err := handler.RefreshToken(&auth)
 	if err != nil {
-		return auth, err
+		return saved, err
 	}
 
-	auth.OAuth2AccessToken = tokenResp.AccessToken
-	if tokenResp.RefreshToken != "" {
-		auth.OAuth2RefreshToken = tokenResp.RefreshToken
+	if err := a.cacheOAuth2Token(&auth, tokenResp, ""); err != nil {
+		return saved, err
 	}
 
-	return auth, nil
+	return oauth2Result(saved, auth), nil
 }
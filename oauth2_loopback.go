@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	oauth2CallbackPath    = "/callback"
+	oauth2CallbackTimeout = 5 * time.Minute
+)
+
+// OAuth2FlowEvent is emitted as oauth2:started once the browser has been opened.
+// ID can be passed to CancelRequest to stop waiting for the callback.
+type OAuth2FlowEvent struct {
+	ID          string `json:"id"`
+	AuthUrl     string `json:"authUrl"`
+	RedirectUrl string `json:"redirectUrl"`
+}
+
+type oauth2Callback struct {
+	params url.Values
+	err    error
+}
+
+// loopbackServer is the temporary listener on 127.0.0.1 that receives the
+// authorization redirect.
+type loopbackServer struct {
+	listener    net.Listener
+	server      *http.Server
+	redirectUrl string
+	result      chan oauth2Callback
+}
+
+// startLoopbackServer listens on the host and port of redirectUrl, or on a free
+// port of 127.0.0.1 when no redirect URL is configured. responseKey is the
+// parameter the redirect must carry: "code", or "access_token" for the implicit
+// grant, whose fragment is relayed back to the server by a small script.
+func startLoopbackServer(redirectUrl string, state string, responseKey string) (*loopbackServer, error) {
+	addr := "127.0.0.1:0"
+	path := oauth2CallbackPath
+	if redirectUrl != "" {
+		u, err := url.Parse(redirectUrl)
+		if err != nil {
+			return nil, fmt.Errorf("无效的回调URL: %w", err)
+		}
+		if u.Scheme != "http" || !isLoopbackHost(u.Hostname()) {
+			return nil, fmt.Errorf("回调URL必须是本机的 http 地址, 例如 http://127.0.0.1:8080/callback")
+		}
+		port := u.Port()
+		if port == "" {
+			port = "80"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+		// Without a path the authorization server redirects to "/".
+		path = u.Path
+		if path == "" {
+			path = "/"
+		}
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("启动本地回调服务失败: %w", err)
+	}
+
+	s := &loopbackServer{
+		listener: listener,
+		result:   make(chan oauth2Callback, 1),
+	}
+	if redirectUrl != "" {
+		s.redirectUrl = redirectUrl
+	} else {
+		s.redirectUrl = "http://" + listener.Addr().String() + path
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		// A "/" pattern matches every path, /favicon.ico included.
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		query := r.URL.Query()
+		if responseKey == "access_token" && r.URL.RawQuery == "" {
+			fmt.Fprint(w, fragmentRelayPage)
+			return
+		}
+
+		// Anything without our state did not come from the authorization
+		// server, so it is refused without ending the flow.
+		if query.Get("state") != state {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "<html><body><h3>授权失败</h3><p>state 不匹配, 授权响应可能被篡改</p></body></html>")
+			return
+		}
+
+		callback := oauth2Callback{params: query}
+		switch {
+		case query.Get("error") != "":
+			callback.err = fmt.Errorf("授权失败: %s %s", query.Get("error"), query.Get("error_description"))
+		case query.Get(responseKey) == "":
+			callback.err = fmt.Errorf("回调中缺少 %s", responseKey)
+		}
+
+		if callback.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<html><body><h3>授权失败</h3><p>%s</p></body></html>", escapeHTML(callback.err.Error()))
+		} else {
+			fmt.Fprint(w, "<html><body><h3>授权成功</h3><p>可以关闭此页面并返回 PostGo。</p></body></html>")
+		}
+
+		select {
+		case s.result <- callback:
+		default:
+		}
+	})
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go s.server.Serve(listener)
+
+	return s, nil
+}
+
+func (s *loopbackServer) wait(ctx context.Context) (url.Values, error) {
+	select {
+	case callback := <-s.result:
+		return callback.params, callback.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("等待授权回调超时")
+		}
+		return nil, fmt.Errorf("授权已取消")
+	}
+}
+
+func (s *loopbackServer) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.server.Shutdown(ctx)
+}
+
+// fragmentRelayPage turns the implicit grant's #fragment, which browsers never
+// send to the server, into a query string on the same callback path.
+const fragmentRelayPage = `<html><body><script>
+if (location.hash.length > 1) {
+  location.replace(location.pathname + "?" + location.hash.substring(1));
+} else {
+  document.body.textContent = "回调中缺少令牌";
+}
+</script></body></html>`
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
+
+// randomURLToken returns n random bytes encoded as unpadded base64url.
+func randomURLToken(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// newPKCE returns a code verifier and its S256 code challenge (RFC 7636).
+func newPKCE() (verifier string, challenge string) {
+	verifier = randomURLToken(32)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
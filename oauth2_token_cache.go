@@ -0,0 +1,341 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2RefreshMargin is how long before expiry a cached token is refreshed.
+const oauth2RefreshMargin = time.Minute
+
+// OAuth2CachedToken is a token obtained for one auth configuration.
+type OAuth2CachedToken struct {
+	Key          string    `json:"key"`
+	TokenUrl     string    `json:"tokenUrl"`
+	ClientId     string    `json:"clientId,omitempty"`
+	GrantType    string    `json:"grantType,omitempty"`
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	TokenType    string    `json:"tokenType,omitempty"`
+	IdToken      string    `json:"idToken,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	ExpiresAt    int64     `json:"expiresAt,omitempty"` // unix seconds, 0 when the server sent no expires_in
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// expiresWithin reports whether a token expiring at expiresAt (unix seconds)
+// expires in less than d. Tokens without a known expiry never do.
+func expiresWithin(expiresAt int64, d time.Duration) bool {
+	return expiresAt > 0 && time.Now().Add(d).Unix() >= expiresAt
+}
+
+// OAuth2TokenCache persists tokens keyed by the auth configuration that
+// obtained them, so every request sharing that configuration reuses them.
+type OAuth2TokenCache struct {
+	mu       sync.RWMutex
+	tokens   []OAuth2CachedToken
+	filePath string
+
+	// refreshMu serialises refreshes so concurrent requests don't all refresh.
+	refreshMu sync.Mutex
+}
+
+func NewOAuth2TokenCache() (*OAuth2TokenCache, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dataDir := filepath.Join(homeDir, ".postgo")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	filePath := filepath.Join(dataDir, "oauth2_tokens.json")
+
+	cache := &OAuth2TokenCache{
+		tokens:   make([]OAuth2CachedToken, 0),
+		filePath: filePath,
+	}
+
+	if err := cache.load(); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return cache, nil
+}
+
+// oauth2CacheKey identifies an auth configuration by token endpoint, client,
+// grant and scope, and by whom the token is for: the resource owner of a
+// password grant, the subject and actor tokens of a token exchange, or the
+// assertion of a JWT bearer grant (its payload when the assertion is signed
+// locally). Only a hash of these ends up in the key.
+func oauth2CacheKey(auth *Auth) string {
+	parts := []string{
+		auth.OAuth2TokenUrl,
+		auth.OAuth2ClientId,
+		auth.OAuth2GrantType,
+		auth.OAuth2Scope,
+	}
+
+	subject := []string{auth.OAuth2Username, auth.OAuth2SubjectToken, auth.OAuth2ActorToken, auth.OAuth2Assertion}
+	if auth.OAuth2GrantType == "jwt_bearer" && auth.OAuth2Assertion == "" {
+		subject = append(subject, auth.JwtPayload)
+	}
+	// Configurations without a subject keep the keys they were cached under.
+	if strings.Join(subject, "") != "" {
+		parts = append(parts, subject...)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *OAuth2TokenCache) Get(key string) *OAuth2CachedToken {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, token := range c.tokens {
+		if token.Key == key {
+			return &token
+		}
+	}
+
+	return nil
+}
+
+// Put stores a token response for auth and returns the cached entry. A refresh
+// response without a new refresh token keeps the previous one.
+func (c *OAuth2TokenCache) Put(auth *Auth, resp *OAuth2TokenResponse) (OAuth2CachedToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token := OAuth2CachedToken{
+		Key:          oauth2CacheKey(auth),
+		TokenUrl:     auth.OAuth2TokenUrl,
+		ClientId:     auth.OAuth2ClientId,
+		GrantType:    auth.OAuth2GrantType,
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		TokenType:    resp.TokenType,
+		IdToken:      resp.IdToken,
+		Scope:        resp.Scope,
+		UpdatedAt:    time.Now(),
+	}
+	if resp.ExpiresIn > 0 {
+		token.ExpiresAt = token.UpdatedAt.Unix() + int64(resp.ExpiresIn)
+	}
+	if token.Scope == "" {
+		token.Scope = auth.OAuth2Scope
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = auth.OAuth2RefreshToken
+	}
+
+	found := false
+	for i, t := range c.tokens {
+		if t.Key == token.Key {
+			if token.RefreshToken == "" {
+				token.RefreshToken = t.RefreshToken
+			}
+			c.tokens[i] = token
+			found = true
+			break
+		}
+	}
+	if !found {
+		c.tokens = append(c.tokens, token)
+	}
+
+	if err := c.save(); err != nil {
+		return token, fmt.Errorf("failed to save oauth2 token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (c *OAuth2TokenCache) GetAll() []OAuth2CachedToken {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]OAuth2CachedToken, len(c.tokens))
+	copy(result, c.tokens)
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UpdatedAt.After(result[j].UpdatedAt)
+	})
+
+	return result
+}
+
+func (c *OAuth2TokenCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, token := range c.tokens {
+		if token.Key == key {
+			c.tokens = append(c.tokens[:i], c.tokens[i+1:]...)
+			return c.save()
+		}
+	}
+
+	return nil
+}
+
+func (c *OAuth2TokenCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens = make([]OAuth2CachedToken, 0)
+	return c.save()
+}
+
+func (c *OAuth2TokenCache) load() error {
+	data, err := os.ReadFile(c.filePath)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &c.tokens)
+}
+
+func (c *OAuth2TokenCache) save() error {
+	data, err := json.MarshalIndent(c.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.filePath, data, 0600)
+}
+
+// applyCachedToken copies the cached token onto auth.
+func applyCachedToken(auth *Auth, token OAuth2CachedToken) {
+	auth.OAuth2AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		auth.OAuth2RefreshToken = token.RefreshToken
+	}
+	auth.OAuth2TokenType = token.TokenType
+	if token.IdToken != "" {
+		auth.OAuth2IdToken = token.IdToken
+	}
+	auth.OAuth2ExpiresAt = token.ExpiresAt
+}
+
+// oauth2Result copies what a flow run on the substituted auth obtained back onto
+// the saved auth: the tokens, and endpoints discovery filled in where the saved
+// auth left them empty. Placeholders in the saved auth are kept.
+func oauth2Result(saved Auth, resolved Auth) Auth {
+	saved.OAuth2AccessToken = resolved.OAuth2AccessToken
+	saved.OAuth2RefreshToken = resolved.OAuth2RefreshToken
+	saved.OAuth2TokenType = resolved.OAuth2TokenType
+	saved.OAuth2ExpiresAt = resolved.OAuth2ExpiresAt
+	saved.OAuth2IdToken = resolved.OAuth2IdToken
+	saved.OAuth2IdTokenClaims = resolved.OAuth2IdTokenClaims
+
+	for _, endpoint := range []struct{ saved, resolved *string }{
+		{&saved.OAuth2AuthUrl, &resolved.OAuth2AuthUrl},
+		{&saved.OAuth2TokenUrl, &resolved.OAuth2TokenUrl},
+		{&saved.OAuth2DeviceAuthUrl, &resolved.OAuth2DeviceAuthUrl},
+	} {
+		if *endpoint.saved == "" {
+			*endpoint.saved = *endpoint.resolved
+		}
+	}
+	return saved
+}
+
+// ensureOAuth2Token fills auth with the cached token, refreshing it first when
+// it is about to expire or when force is set (after a 401). It reports whether
+// a new token was obtained.
+func (a *App) ensureOAuth2Token(auth *Auth, force bool) (bool, error) {
+	key := oauth2CacheKey(auth)
+	cached := a.oauth2Tokens.Get(key)
+	if cached != nil {
+		applyCachedToken(auth, *cached)
+	}
+
+	if !force && (auth.OAuth2AccessToken == "" || !expiresWithin(auth.OAuth2ExpiresAt, oauth2RefreshMargin)) {
+		return false, nil
+	}
+
+	a.oauth2Tokens.refreshMu.Lock()
+	defer a.oauth2Tokens.refreshMu.Unlock()
+
+	// Another request may have refreshed while we waited for the lock.
+	if latest := a.oauth2Tokens.Get(key); latest != nil && (cached == nil || latest.UpdatedAt.After(cached.UpdatedAt)) {
+		applyCachedToken(auth, *latest)
+		return true, nil
+	}
+
+	handler := NewOAuth2Handler(a)
+	var tokenResp *OAuth2TokenResponse
+	var err error
+	switch {
+	case auth.OAuth2RefreshToken != "":
+		tokenResp, err = handler.RefreshToken(auth)
+	case auth.OAuth2GrantType == "client_credentials":
+		tokenResp, err = handler.GetClientCredentialsToken(auth)
+	case auth.OAuth2GrantType == "token_exchange":
+		tokenResp, err = handler.GetTokenExchangeToken(auth)
+	case auth.OAuth2GrantType == "jwt_bearer":
+		tokenResp, err = handler.GetJwtBearerToken(auth)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("刷新令牌失败: %w", err)
+	}
+
+	token, err := a.oauth2Tokens.Put(auth, tokenResp)
+	if err != nil {
+		fmt.Printf("Failed to cache oauth2 token: %v\n", err)
+	}
+	applyCachedToken(auth, token)
+	return true, nil
+}
+
+// cacheOAuth2Token verifies the ID token of an OpenID Connect response, then
+// stores the freshly obtained token and copies it onto auth.
+func (a *App) cacheOAuth2Token(auth *Auth, tokenResp *OAuth2TokenResponse, nonce string) error {
+	if auth.OAuth2Issuer != "" && tokenResp.IdToken != "" {
+		config, err := fetchOIDCConfiguration(auth.OAuth2Issuer)
+		if err != nil {
+			return err
+		}
+		claims, err := verifyIDToken(tokenResp.IdToken, config, auth, nonce)
+		if err != nil {
+			return fmt.Errorf("ID Token 校验失败: %w", err)
+		}
+		auth.OAuth2IdTokenClaims = claims
+	}
+
+	token, err := a.oauth2Tokens.Put(auth, tokenResp)
+	if err != nil {
+		fmt.Printf("Failed to cache oauth2 token: %v\n", err)
+	}
+	applyCachedToken(auth, token)
+	return nil
+}
+
+func (a *App) GetOAuth2Tokens() []OAuth2CachedToken {
+	return a.oauth2Tokens.GetAll()
+}
+
+func (a *App) DeleteOAuth2Token(key string) error {
+	return a.oauth2Tokens.Delete(key)
+}
+
+func (a *App) ClearOAuth2Tokens() error {
+	return a.oauth2Tokens.Clear()
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idTokenLeeway tolerates clock skew between PostGo and the provider.
+const idTokenLeeway = time.Minute
+
+// OIDCConfiguration is the subset of the discovery document PostGo uses.
+type OIDCConfiguration struct {
+	Issuer                      string   `json:"issuer"`
+	AuthorizationEndpoint       string   `json:"authorization_endpoint"`
+	TokenEndpoint               string   `json:"token_endpoint"`
+	UserinfoEndpoint            string   `json:"userinfo_endpoint,omitempty"`
+	JwksUri                     string   `json:"jwks_uri"`
+	DeviceAuthorizationEndpoint string   `json:"device_authorization_endpoint,omitempty"`
+	ScopesSupported             []string `json:"scopes_supported,omitempty"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// oidcCache keeps discovery documents and key sets for the lifetime of the app.
+var oidcCache = struct {
+	sync.Mutex
+	configs map[string]*OIDCConfiguration
+	keys    map[string][]jsonWebKey
+}{
+	configs: make(map[string]*OIDCConfiguration),
+	keys:    make(map[string][]jsonWebKey),
+}
+
+// discover fills the endpoints of auth from its issuer's discovery document.
+// Endpoints that are already set are kept.
+func (h *OAuth2Handler) discover(auth *Auth) (*OIDCConfiguration, error) {
+	if auth.OAuth2Issuer == "" {
+		return nil, nil
+	}
+
+	config, err := fetchOIDCConfiguration(auth.OAuth2Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth.OAuth2AuthUrl == "" {
+		auth.OAuth2AuthUrl = config.AuthorizationEndpoint
+	}
+	if auth.OAuth2TokenUrl == "" {
+		auth.OAuth2TokenUrl = config.TokenEndpoint
+	}
+	if auth.OAuth2DeviceAuthUrl == "" {
+		auth.OAuth2DeviceAuthUrl = config.DeviceAuthorizationEndpoint
+	}
+	return config, nil
+}
+
+func fetchOIDCConfiguration(issuer string) (*OIDCConfiguration, error) {
+	issuer = strings.TrimSuffix(strings.TrimSpace(issuer), "/")
+
+	oidcCache.Lock()
+	config, ok := oidcCache.configs[issuer]
+	oidcCache.Unlock()
+	if ok {
+		return config, nil
+	}
+
+	body, err := fetchJSON(issuer+"/.well-known/openid-configuration", "")
+	if err != nil {
+		return nil, fmt.Errorf("获取 OIDC 配置失败: %w", err)
+	}
+
+	config = &OIDCConfiguration{}
+	if err := json.Unmarshal(body, config); err != nil {
+		return nil, fmt.Errorf("解析 OIDC 配置失败: %w", err)
+	}
+	if strings.TrimSuffix(config.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC 配置中的 issuer (%s) 与填写的 issuer 不一致", config.Issuer)
+	}
+
+	oidcCache.Lock()
+	oidcCache.configs[issuer] = config
+	oidcCache.Unlock()
+	return config, nil
+}
+
+func fetchJSON(endpoint string, bearerToken string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("状态码 %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// fetchJWKS returns the provider keys, reloading them when refresh is set so
+// rotated keys are picked up.
+func fetchJWKS(jwksUri string, refresh bool) ([]jsonWebKey, error) {
+	oidcCache.Lock()
+	keys, ok := oidcCache.keys[jwksUri]
+	oidcCache.Unlock()
+	if ok && !refresh {
+		return keys, nil
+	}
+
+	body, err := fetchJSON(jwksUri, "")
+	if err != nil {
+		return nil, fmt.Errorf("获取 JWKS 失败: %w", err)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("解析 JWKS 失败: %w", err)
+	}
+
+	oidcCache.Lock()
+	oidcCache.keys[jwksUri] = set.Keys
+	oidcCache.Unlock()
+	return set.Keys, nil
+}
+
+// decodeJWT splits a compact JWS without verifying it.
+func decodeJWT(token string) (header map[string]interface{}, claims map[string]interface{}, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, fmt.Errorf("不是有效的 JWT")
+	}
+	for i, target := range []*map[string]interface{}{&header, &claims} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("JWT 解码失败: %w", err)
+		}
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.UseNumber()
+		if err := decoder.Decode(target); err != nil {
+			return nil, nil, fmt.Errorf("JWT 解码失败: %w", err)
+		}
+	}
+	return header, claims, nil
+}
+
+// verifyIDToken checks the signature of idToken against the provider's JWKS
+// (or the client secret for HS256) and validates iss, aud, exp and nonce.
+func verifyIDToken(idToken string, config *OIDCConfiguration, auth *Auth, nonce string) (map[string]interface{}, error) {
+	header, claims, err := decodeJWT(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, _ := header["alg"].(string)
+	kid, _ := header["kid"].(string)
+	parts := strings.Split(idToken, ".")
+	signingInput := []byte(parts[0] + "." + parts[1])
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("签名解码失败: %w", err)
+	}
+
+	if strings.HasPrefix(alg, "HS") {
+		if err := verifyHMAC(alg, []byte(auth.OAuth2ClientSecret), signingInput, signature); err != nil {
+			return nil, err
+		}
+	} else {
+		key, err := findJWK(config.JwksUri, kid, alg)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyWithJWK(alg, key, signingInput, signature); err != nil {
+			return nil, err
+		}
+	}
+
+	if iss, _ := claims["iss"].(string); iss != config.Issuer {
+		return nil, fmt.Errorf("iss 不匹配: %s", iss)
+	}
+	if !audienceContains(claims["aud"], auth.OAuth2ClientId) {
+		return nil, fmt.Errorf("aud 不包含客户端ID %s", auth.OAuth2ClientId)
+	}
+	exp, err := numericClaim(claims["exp"])
+	if err != nil || time.Now().Add(-idTokenLeeway).Unix() > exp {
+		return nil, fmt.Errorf("ID Token 已过期")
+	}
+	if nonce != "" {
+		if got, _ := claims["nonce"].(string); got != nonce {
+			return nil, fmt.Errorf("nonce 不匹配")
+		}
+	}
+
+	return claims, nil
+}
+
+func findJWK(jwksUri string, kid string, alg string) (*jsonWebKey, error) {
+	if jwksUri == "" {
+		return nil, fmt.Errorf("OIDC 配置中缺少 jwks_uri")
+	}
+	for _, refresh := range []bool{false, true} {
+		keys, err := fetchJWKS(jwksUri, refresh)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range keys {
+			if key.Use != "" && key.Use != "sig" {
+				continue
+			}
+			if kid != "" && key.Kid != kid {
+				continue
+			}
+			if key.Alg != "" && key.Alg != alg {
+				continue
+			}
+			return &keys[i], nil
+		}
+	}
+	return nil, fmt.Errorf("JWKS 中找不到密钥 %s", kid)
+}
+
+func verifyHMAC(alg string, secret []byte, input []byte, signature []byte) error {
+	var mac []byte
+	switch alg {
+	case "HS256":
+		m := hmac.New(sha256.New, secret)
+		m.Write(input)
+		mac = m.Sum(nil)
+	case "HS384":
+		m := hmac.New(sha512.New384, secret)
+		m.Write(input)
+		mac = m.Sum(nil)
+	case "HS512":
+		m := hmac.New(sha512.New, secret)
+		m.Write(input)
+		mac = m.Sum(nil)
+	default:
+		return fmt.Errorf("不支持的签名算法: %s", alg)
+	}
+	if !hmac.Equal(mac, signature) {
+		return fmt.Errorf("ID Token 签名无效")
+	}
+	return nil
+}
+
+func verifyWithJWK(alg string, key *jsonWebKey, input []byte, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("不支持的签名算法: %s", alg)
+	}
+	h := hash.New()
+	h.Write(input)
+	digest := h.Sum(nil)
+
+	switch {
+	case strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS"):
+		if key.Kty != "RSA" {
+			return fmt.Errorf("密钥类型 %s 与算法 %s 不匹配", key.Kty, alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return fmt.Errorf("无效的 RSA 公钥: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return fmt.Errorf("无效的 RSA 公钥: %w", err)
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if strings.HasPrefix(alg, "PS") {
+			err = rsa.VerifyPSS(pub, hash, digest, signature, nil)
+		} else {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+		}
+		if err != nil {
+			return fmt.Errorf("ID Token 签名无效")
+		}
+		return nil
+	case strings.HasPrefix(alg, "ES"):
+		if key.Kty != "EC" {
+			return fmt.Errorf("密钥类型 %s 与算法 %s 不匹配", key.Kty, alg)
+		}
+		var curve elliptic.Curve
+		switch key.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return fmt.Errorf("不支持的椭圆曲线: %s", key.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil {
+			return fmt.Errorf("无效的 EC 公钥: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(key.Y)
+		if err != nil {
+			return fmt.Errorf("无效的 EC 公钥: %w", err)
+		}
+		// JWS encodes r and s as fixed-size big-endian integers of the curve size.
+		size := (curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("ID Token 签名无效")
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		half := size
+		r := new(big.Int).SetBytes(signature[:half])
+		s := new(big.Int).SetBytes(signature[half:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("ID Token 签名无效")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的签名算法: %s", alg)
+	}
+}
+
+func audienceContains(aud interface{}, clientId string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientId
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == clientId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numericClaim(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return int64(f), err
+	case float64:
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("不是数字")
+}
+
+// DiscoverOIDC fills the OAuth2 endpoints of auth from its issuer.
+func (a *App) DiscoverOIDC(saved Auth) (Auth, error) {
+	auth := a.substituteAuthVariables(saved)
+	if auth.OAuth2Issuer == "" {
+		return saved, fmt.Errorf("Issuer 不能为空")
+	}
+	if _, err := NewOAuth2Handler(a).discover(&auth); err != nil {
+		return saved, err
+	}
+	return oauth2Result(saved, auth), nil
+}
+
+// GetOIDCUserInfo calls the provider's userinfo endpoint with the access token.
+func (a *App) GetOIDCUserInfo(auth Auth) (map[string]interface{}, error) {
+	auth = a.substituteAuthVariables(auth)
+	if auth.OAuth2Issuer == "" {
+		return nil, fmt.Errorf("Issuer 不能为空")
+	}
+	if auth.OAuth2AccessToken == "" {
+		return nil, fmt.Errorf("访问令牌不能为空")
+	}
+
+	config, err := fetchOIDCConfiguration(auth.OAuth2Issuer)
+	if err != nil {
+		return nil, err
+	}
+	if config.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("OIDC 配置中缺少 userinfo_endpoint")
+	}
+
+	body, err := fetchJSON(config.UserinfoEndpoint, auth.OAuth2AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %w", err)
+	}
+
+	// Signed userinfo responses are a JWT rather than JSON.
+	if trimmed := strings.TrimSpace(string(body)); !strings.HasPrefix(trimmed, "{") {
+		_, claims, err := decodeJWT(trimmed)
+		return claims, err
+	}
+
+	var info map[string]interface{}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("解析用户信息失败: %w", err)
+	}
+	return info, nil
+}
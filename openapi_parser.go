@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"sort"
 	"strings"
 
-	"gopkg.in/yaml.v3"
+	"github.com/google/uuid"
 )
 
 type OpenAPISpec struct {
@@ -14,6 +17,45 @@ type OpenAPISpec struct {
 	Info    OpenAPIInfo           `json:"info" yaml:"info"`
 	Paths   map[string]PathItem   `json:"paths" yaml:"paths"`
 	Servers []OpenAPIServer       `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Swagger string                `json:"swagger,omitempty" yaml:"swagger,omitempty"` // set when converted from Swagger 2.0
+	Security []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+
+	Components *Components `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+// Components holds the reusable objects of a spec. $refs to them are inlined
+// while parsing, so they are kept mostly for reference and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema            `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Parameters      map[string]Parameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBodies   map[string]OpenAPIRequestBody `json:"requestBodies,omitempty" yaml:"requestBodies,omitempty"`
+	Responses       map[string]Response           `json:"responses,omitempty" yaml:"responses,omitempty"`
+	SecuritySchemes map[string]SecurityScheme     `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type             string      `json:"type" yaml:"type"` // apiKey, http, oauth2, openIdConnect
+	Description      string      `json:"description,omitempty" yaml:"description,omitempty"`
+	Name             string      `json:"name,omitempty" yaml:"name,omitempty"`
+	In               string      `json:"in,omitempty" yaml:"in,omitempty"`
+	Scheme           string      `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	BearerFormat     string      `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+	Flows            *OAuthFlows `json:"flows,omitempty" yaml:"flows,omitempty"`
+	OpenIdConnectUrl string      `json:"openIdConnectUrl,omitempty" yaml:"openIdConnectUrl,omitempty"`
+}
+
+type OAuthFlows struct {
+	Implicit          *OAuthFlow `json:"implicit,omitempty" yaml:"implicit,omitempty"`
+	Password          *OAuthFlow `json:"password,omitempty" yaml:"password,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty" yaml:"clientCredentials,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty" yaml:"authorizationCode,omitempty"`
+}
+
+type OAuthFlow struct {
+	AuthorizationUrl string            `json:"authorizationUrl,omitempty" yaml:"authorizationUrl,omitempty"`
+	TokenUrl         string            `json:"tokenUrl,omitempty" yaml:"tokenUrl,omitempty"`
+	RefreshUrl       string            `json:"refreshUrl,omitempty" yaml:"refreshUrl,omitempty"`
+	Scopes           map[string]string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
 }
 
 type OpenAPIInfo struct {
@@ -35,14 +77,36 @@ type PathItem struct {
 	Patch  *Operation `json:"patch,omitempty" yaml:"patch,omitempty"`
 	Head   *Operation `json:"head,omitempty" yaml:"head,omitempty"`
 	Options *Operation `json:"options,omitempty" yaml:"options,omitempty"`
+	Parameters []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// operations returns the operations defined on the path item by HTTP method.
+func (p PathItem) operations() map[string]*Operation {
+	operations := make(map[string]*Operation)
+	for method, operation := range map[string]*Operation{
+		"GET":     p.Get,
+		"POST":    p.Post,
+		"PUT":     p.Put,
+		"DELETE":  p.Delete,
+		"PATCH":   p.Patch,
+		"HEAD":    p.Head,
+		"OPTIONS": p.Options,
+	} {
+		if operation != nil {
+			operations[method] = operation
+		}
+	}
+	return operations
 }
 
 type Operation struct {
+	OperationId string                `json:"operationId,omitempty" yaml:"operationId,omitempty"`
 	Summary     string                `json:"summary,omitempty" yaml:"summary,omitempty"`
 	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
 	Parameters  []Parameter           `json:"parameters,omitempty" yaml:"parameters,omitempty"`
 	RequestBody *OpenAPIRequestBody   `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
 	Responses   map[string]Response   `json:"responses,omitempty" yaml:"responses,omitempty"`
+	Security    *[]SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"` // nil inherits the spec's security, empty means none
 }
 
 type Parameter struct {
@@ -51,6 +115,7 @@ type Parameter struct {
 	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
 	Required    bool        `json:"required,omitempty" yaml:"required,omitempty"`
 	Schema      *Schema     `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example     interface{} `json:"example,omitempty" yaml:"example,omitempty"`
 }
 
 type OpenAPIRequestBody struct {
@@ -60,7 +125,14 @@ type OpenAPIRequestBody struct {
 }
 
 type MediaType struct {
-	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Schema   *Schema                   `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Example  interface{}               `json:"example,omitempty" yaml:"example,omitempty"`
+	Examples map[string]OpenAPIExample `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
+
+type OpenAPIExample struct {
+	Summary string      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Value   interface{} `json:"value,omitempty" yaml:"value,omitempty"`
 }
 
 type Response struct {
@@ -69,27 +141,141 @@ type Response struct {
 }
 
 type Schema struct {
-	Type       string             `json:"type,omitempty" yaml:"type,omitempty"`
-	Properties map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
-	Items      *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Ref         string             `json:"$ref,omitempty" yaml:"$ref,omitempty"` // only left on recursive references
+	Type        string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format      string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Items       *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Required    []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Example     interface{}        `json:"example,omitempty" yaml:"example,omitempty"`
+	Default     interface{}        `json:"default,omitempty" yaml:"default,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Nullable    bool               `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+
+	// OpenAPI 3.1 (JSON Schema 2020-12) keywords.
+	Const    interface{}   `json:"const,omitempty" yaml:"const,omitempty"`
+	Examples []interface{} `json:"examples,omitempty" yaml:"examples,omitempty"`
 }
 
-func ParseOpenAPI(data []byte, format string) (*OpenAPISpec, error) {
-	var spec OpenAPISpec
+// UnmarshalJSON accepts the 3.1 forms of type, which may be a list such as
+// ["string", "null"], and examples, which 3.0 tooling sometimes writes as a map.
+// Boolean schemas, such as "items": true or a property declared as false,
+// become an empty schema since nothing is generated from either.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); bytes.Equal(trimmed, []byte("true")) || bytes.Equal(trimmed, []byte("false")) {
+		*s = Schema{}
+		return nil
+	}
 
-	switch strings.ToLower(format) {
-	case "json":
-		if err := json.Unmarshal(data, &spec); err != nil {
-			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	type plainSchema Schema
+	var raw struct {
+		plainSchema
+		Type     json.RawMessage `json:"type,omitempty"`
+		Examples json.RawMessage `json:"examples,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = Schema(raw.plainSchema)
+
+	if len(raw.Type) > 0 {
+		var types []string
+		if err := json.Unmarshal(raw.Type, &s.Type); err != nil {
+			if err := json.Unmarshal(raw.Type, &types); err != nil {
+				return fmt.Errorf("invalid schema type: %s", raw.Type)
+			}
+			s.Type = ""
+			for _, t := range types {
+				if t == "null" {
+					s.Nullable = true
+				} else if s.Type == "" {
+					s.Type = t
+				}
+			}
 		}
-	case "yaml", "yml":
-		if err := yaml.Unmarshal(data, &spec); err != nil {
-			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	if len(raw.Examples) > 0 {
+		if err := json.Unmarshal(raw.Examples, &s.Examples); err != nil {
+			s.Examples = nil
 		}
+	}
+	return nil
+}
+
+// ParseOpenAPI parses a spec whose $refs are all internal. Use ParseOpenAPIFile
+// for specs that reference other files. An empty or "auto" format accepts
+// either JSON or YAML.
+func ParseOpenAPI(data []byte, format string) (*OpenAPISpec, error) {
+	switch strings.ToLower(format) {
+	case "", "auto", "json", "yaml", "yml":
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
 
+	return parseOpenAPIDocument(newRefResolver(nil), data, "")
+}
+
+// ParseOpenAPIFile parses the spec at path, resolving $refs to other files
+// relative to it.
+func ParseOpenAPIFile(path string) (*OpenAPISpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseOpenAPIDocument(newRefResolver(loadSpecFile), data, path)
+}
+
+func parseOpenAPIDocument(resolver *refResolver, data []byte, location string) (*OpenAPISpec, error) {
+	doc, err := resolver.resolveDocument(data, location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("not an OpenAPI or Swagger document")
+	}
+	// An unquoted YAML version such as 2.0 is decoded as a number.
+	switch {
+	case root["swagger"] != nil:
+		version := fmt.Sprint(root["swagger"])
+		if version == "2" {
+			version = "2.0"
+		}
+		if !strings.HasPrefix(version, "2.") {
+			return nil, fmt.Errorf("unsupported Swagger version: %s", version)
+		}
+		root["swagger"] = version
+		root = convertSwagger2(root)
+	case root["openapi"] != nil:
+		version := fmt.Sprint(root["openapi"])
+		if version == "3" {
+			version = "3.0"
+		}
+		if !strings.HasPrefix(version, "3.") {
+			return nil, fmt.Errorf("unsupported OpenAPI version: %s", version)
+		}
+		root["openapi"] = version
+	default:
+		return nil, fmt.Errorf("not an OpenAPI or Swagger document: missing openapi or swagger version")
+	}
+
+	// The resolved tree is plain JSON data; round-trip it into the typed model.
+	resolved, err := json.Marshal(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	var spec OpenAPISpec
+	if err := json.Unmarshal(resolved, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+
 	return &spec, nil
 }
 
@@ -100,25 +286,41 @@ func ConvertOpenAPIToRequests(spec *OpenAPISpec, projectId string, baseURL strin
 	// The path will be stored as relative (e.g. "/users") and the frontend/runtime 
 	// will prepend the project's Base URL when executing or viewing the request.
 
-	for path, pathItem := range spec.Paths {
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
 
-		operations := map[string]*Operation{
-			"GET":     pathItem.Get,
-			"POST":    pathItem.Post,
-			"PUT":     pathItem.Put,
-			"DELETE":  pathItem.Delete,
-			"PATCH":   pathItem.Patch,
-			"HEAD":    pathItem.Head,
-			"OPTIONS": pathItem.Options,
+	operationIds := make(map[string]int)
+	for _, pathItem := range spec.Paths {
+		for _, operation := range pathItem.operations() {
+			if operation.OperationId != "" {
+				operationIds[operation.OperationId]++
+			}
 		}
+	}
+
+	for _, path := range paths {
+		pathItem := spec.Paths[path]
+		operations := pathItem.operations()
+		methods := make([]string, 0, len(operations))
+		for method := range operations {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			operation := operations[method]
 
-		for method, operation := range operations {
-			if operation == nil {
-				continue
+			// operationId is stable across path renames, but only usable when unique.
+			operationKey := method + " " + path
+			if operation.OperationId != "" && operationIds[operation.OperationId] == 1 {
+				operationKey = operation.OperationId
 			}
 
 			req := HttpRequest{
-				ID:        fmt.Sprintf("req-%d", len(requests)),
+				ID:        uuid.New().String(),
 				Name:      operation.Summary,
 				Method:    HttpMethod(method),
 				URL:       path,
@@ -126,6 +328,7 @@ func ConvertOpenAPIToRequests(spec *OpenAPISpec, projectId string, baseURL strin
 				Params:    []KeyValue{},
 				ProjectId: projectId,
 				Body:      &RequestBody{Type: "raw"},
+				Auth:      operationAuth(spec, operation),
 			}
 
 			if req.Name == "" {
@@ -135,44 +338,53 @@ func ConvertOpenAPIToRequests(spec *OpenAPISpec, projectId string, baseURL strin
 				req.Name = fmt.Sprintf("%s %s", method, path)
 			}
 
-			for _, param := range operation.Parameters {
+			for _, param := range mergeParameters(pathItem.Parameters, operation.Parameters) {
 				if param.In == "query" {
 					req.Params = append(req.Params, KeyValue{
-						Key:     param.Name,
-						Value:   "",
-						Enabled: !param.Required,
+						Key:         param.Name,
+						Value:       "",
+						Enabled:     !param.Required,
+						Description: param.Description,
 					})
 				} else if param.In == "header" {
 					req.Headers = append(req.Headers, KeyValue{
-						Key:     param.Name,
-						Value:   "",
-						Enabled: !param.Required,
+						Key:         param.Name,
+						Value:       "",
+						Enabled:     !param.Required,
+						Description: param.Description,
+					})
+				} else if param.In == "path" {
+					req.PathParams = append(req.PathParams, KeyValue{
+						Key:         param.Name,
+						Value:       "",
+						Enabled:     true,
+						Description: param.Description,
 					})
 				}
 			}
 
 			if operation.RequestBody != nil {
-				for contentType, mediaType := range operation.RequestBody.Content {
+				if contentType, mediaType, ok := preferredMediaType(operation.RequestBody.Content); ok {
 					if strings.Contains(contentType, "json") {
 						req.Headers = append(req.Headers, KeyValue{
 							Key:     "Content-Type",
 							Value:   "application/json",
 							Enabled: true,
 						})
-						if req.Body == nil {
-							req.Body = &RequestBody{}
+						req.Body = &RequestBody{
+							Type:    "raw",
+							Content: mediaTypeExample(mediaType),
 						}
-						req.Body.Type = "raw"
-						req.Body.Content = generateJSONExample(mediaType.Schema)
-					} else if strings.Contains(contentType, "form") {
-						if req.Body == nil {
-							req.Body = &RequestBody{}
+					} else {
+						req.Body = &RequestBody{
+							Type:     "form-data",
+							FormData: generateFormFields(mediaType.Schema),
 						}
-						req.Body.Type = "form-data"
 					}
 				}
 			}
 
+			req.OpenAPI = &OpenAPIOrigin{OperationKey: operationKey, Signature: requestSignature(req)}
 			requests = append(requests, req)
 		}
 	}
@@ -180,21 +392,240 @@ func ConvertOpenAPIToRequests(spec *OpenAPISpec, projectId string, baseURL strin
 	return requests
 }
 
+// preferredMediaType picks the request body media type to generate: the first
+// JSON type in sorted order, else the first form type. The choice must not
+// depend on map order, or re-imports of the same spec would differ.
+func preferredMediaType(content map[string]MediaType) (string, MediaType, bool) {
+	contentTypes := make([]string, 0, len(content))
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+
+	for _, kind := range []string{"json", "form"} {
+		for _, contentType := range contentTypes {
+			if strings.Contains(contentType, kind) {
+				return contentType, content[contentType], true
+			}
+		}
+	}
+	return "", MediaType{}, false
+}
+
+// mergeParameters combines path-level and operation-level parameters; an
+// operation parameter overrides a path parameter with the same name and location.
+func mergeParameters(pathParams, opParams []Parameter) []Parameter {
+	var merged []Parameter
+	for _, param := range pathParams {
+		overridden := false
+		for _, opParam := range opParams {
+			if opParam.Name == param.Name && opParam.In == param.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, param)
+		}
+	}
+	return append(merged, opParams...)
+}
+
 func generateJSONExample(schema *Schema) string {
 	if schema == nil {
 		return "{}"
 	}
 
-	if schema.Type == "object" && schema.Properties != nil {
+	data, err := json.MarshalIndent(exampleValue(schema, 0), "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// mediaTypeExample prefers the examples given in the spec over one generated
+// from the schema.
+func mediaTypeExample(mediaType MediaType) string {
+	example := mediaType.Example
+	if example == nil {
+		names := make([]string, 0, len(mediaType.Examples))
+		for name := range mediaType.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if value := mediaType.Examples[name].Value; value != nil {
+				example = value
+				break
+			}
+		}
+	}
+	if example == nil {
+		return generateJSONExample(mediaType.Schema)
+	}
+
+	data, err := json.MarshalIndent(example, "", "  ")
+	if err != nil {
+		return generateJSONExample(mediaType.Schema)
+	}
+	return string(data)
+}
+
+// maxExampleDepth stops deeply nested (or recursive) schemas.
+const maxExampleDepth = 8
+
+// exampleValue builds an example for schema, honoring example, default, enum
+// and format before falling back to a placeholder for the type.
+func exampleValue(schema *Schema, depth int) interface{} {
+	if schema == nil || schema.Ref != "" || depth > maxExampleDepth {
+		return nil
+	}
+	if schema.Const != nil {
+		return schema.Const
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if len(schema.Examples) > 0 {
+		return schema.Examples[0]
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+
+	if len(schema.AllOf) > 0 {
+		merged := make(map[string]interface{})
+		var last interface{}
+		for _, part := range schema.AllOf {
+			last = exampleValue(part, depth+1)
+			if obj, ok := last.(map[string]interface{}); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		if obj, ok := exampleValue(&Schema{Type: "object", Properties: schema.Properties}, depth).(map[string]interface{}); ok && len(schema.Properties) > 0 {
+			for k, v := range obj {
+				merged[k] = v
+			}
+		}
+		if len(merged) == 0 {
+			return last
+		}
+		return merged
+	}
+	if len(schema.OneOf) > 0 {
+		return exampleValue(schema.OneOf[0], depth+1)
+	}
+	if len(schema.AnyOf) > 0 {
+		return exampleValue(schema.AnyOf[0], depth+1)
+	}
+
+	schemaType := schema.Type
+	if schemaType == "" {
+		switch {
+		case schema.Properties != nil:
+			schemaType = "object"
+		case schema.Items != nil:
+			schemaType = "array"
+		}
+	}
+
+	switch schemaType {
+	case "object":
 		obj := make(map[string]interface{})
-		for key := range schema.Properties {
-			obj[key] = ""
+		for key, property := range schema.Properties {
+			obj[key] = exampleValue(property, depth+1)
+		}
+		return obj
+	case "array":
+		if item := exampleValue(schema.Items, depth+1); item != nil {
+			return []interface{}{item}
 		}
-		data, _ := json.MarshalIndent(obj, "", "  ")
-		return string(data)
+		return []interface{}{}
+	case "integer":
+		if schema.Minimum != nil {
+			return int64(*schema.Minimum)
+		}
+		return 0
+	case "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return 0.0
+	case "boolean":
+		return true
+	case "string":
+		return stringFormatExample(schema.Format)
+	}
+	return nil
+}
+
+func stringFormatExample(format string) string {
+	switch format {
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "time":
+		return "00:00:00"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.168.0.1"
+	case "ipv6":
+		return "::1"
+	case "byte":
+		return "c3RyaW5n"
+	case "binary":
+		return ""
+	case "password":
+		return "password"
 	}
+	return "string"
+}
 
-	return "{}"
+// generateFormFields turns the properties of a form schema into form-data
+// fields; binary properties become file fields.
+func generateFormFields(schema *Schema) []KeyValue {
+	if schema == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]KeyValue, 0, len(names))
+	for _, name := range names {
+		property := schema.Properties[name]
+		field := KeyValue{Key: name, Enabled: true, Type: string(FormFieldText)}
+		if property != nil {
+			field.Description = property.Description
+			if property.Format == "binary" {
+				field.Type = string(FormFieldFile)
+			} else if value := exampleValue(property, 0); value != nil {
+				if str, ok := value.(string); ok {
+					field.Value = str
+				} else if data, err := json.Marshal(value); err == nil {
+					field.Value = string(data)
+				}
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
 }
 
 func ReadFileContent(reader io.Reader) ([]byte, error) {
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth bounds how deep $refs are inlined. maxRefNodes bounds the size
+// of one inlined target and maxInlinedNodes the total inlined into a document,
+// so schemas that share schemas many times over can't blow up. A $ref beyond
+// any of them is kept as it is, like a recursive one.
+const (
+	maxRefDepth     = 64
+	maxRefNodes     = 20000
+	maxInlinedNodes = 2000000
+)
+
+// refResolver inlines $ref nodes of a parsed document. Internal refs point into
+// the document itself; external refs ("common.yaml#/Pet") are loaded relative
+// to the location of the document that contains them.
+type refResolver struct {
+	load    func(location string) ([]byte, error)
+	docs    map[string]interface{}
+	stack   []string
+	targets map[string]resolvedTarget // by location#pointer, each resolved once
+	inlined int
+}
+
+// resolvedTarget is a $ref target with its own refs inlined. size counts its
+// nodes; tooLarge marks targets over maxRefNodes, which are never inlined.
+type resolvedTarget struct {
+	value    interface{}
+	size     int
+	tooLarge bool
+}
+
+func newRefResolver(load func(location string) ([]byte, error)) *refResolver {
+	return &refResolver{
+		load:    load,
+		docs:    make(map[string]interface{}),
+		targets: make(map[string]resolvedTarget),
+	}
+}
+
+// resolveDocument parses data and inlines every $ref in it. location is where
+// data came from and may be empty when the spec was pasted or uploaded.
+func (r *refResolver) resolveDocument(data []byte, location string) (interface{}, error) {
+	doc, err := parseSpecDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	r.docs[location] = doc
+	resolved, _, err := r.resolve(doc, location)
+	return resolved, err
+}
+
+func parseSpecDocument(data []byte) (interface{}, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return normalizeYAML(doc), nil
+}
+
+// normalizeYAML turns map[interface{}]interface{} nodes, which YAML produces
+// for keys such as 200, into map[string]interface{} so they marshal as JSON.
+func normalizeYAML(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeYAML(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = normalizeYAML(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeYAML(value)
+		}
+		return v
+	}
+	return node
+}
+
+// resolve returns node with its $refs inlined and the number of nodes in the
+// result.
+func (r *refResolver) resolve(node interface{}, location string) (interface{}, int, error) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			return r.resolveRef(v, ref, location)
+		}
+		out := make(map[string]interface{}, len(v))
+		size := 1
+		for key, value := range v {
+			resolved, n, err := r.resolve(value, location)
+			if err != nil {
+				return nil, 0, err
+			}
+			out[key] = resolved
+			size += n
+		}
+		return out, size, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		size := 1
+		for i, value := range v {
+			resolved, n, err := r.resolve(value, location)
+			if err != nil {
+				return nil, 0, err
+			}
+			out[i] = resolved
+			size += n
+		}
+		return out, size, nil
+	}
+	return node, 1, nil
+}
+
+func (r *refResolver) resolveRef(node map[string]interface{}, ref string, location string) (interface{}, int, error) {
+	target, pointer, _ := strings.Cut(ref, "#")
+	targetLocation := location
+	if target != "" {
+		targetLocation = joinLocation(location, target)
+	}
+	key := targetLocation + "#" + pointer
+
+	// A ref to a schema that is still being expanded is recursive: keep it as a
+	// ref so the example generator can stop there.
+	for _, active := range r.stack {
+		if active == key {
+			return node, len(node), nil
+		}
+	}
+	if len(r.stack) >= maxRefDepth {
+		return node, len(node), nil
+	}
+
+	resolved, ok := r.targets[key]
+	if !ok {
+		doc, err := r.document(targetLocation)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to resolve $ref %q: %w", ref, err)
+		}
+		value, err := jsonPointer(doc, pointer)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to resolve $ref %q: %w", ref, err)
+		}
+
+		r.stack = append(r.stack, key)
+		resolved.value, resolved.size, err = r.resolve(value, targetLocation)
+		r.stack = r.stack[:len(r.stack)-1]
+		if err != nil {
+			return nil, 0, err
+		}
+		if resolved.size > maxRefNodes {
+			resolved = resolvedTarget{tooLarge: true}
+		}
+		r.targets[key] = resolved
+	}
+
+	if resolved.tooLarge || r.inlined+resolved.size > maxInlinedNodes {
+		return node, len(node), nil
+	}
+	r.inlined += resolved.size
+
+	// Keys next to $ref (allowed since OpenAPI 3.1) override the target. The
+	// target is shared by every ref to it, so it is copied rather than changed.
+	if len(node) > 1 {
+		if m, ok := resolved.value.(map[string]interface{}); ok {
+			merged := make(map[string]interface{}, len(m)+len(node))
+			for k, v := range m {
+				merged[k] = v
+			}
+			for k, v := range node {
+				if k != "$ref" {
+					merged[k] = v
+				}
+			}
+			return merged, resolved.size + len(node), nil
+		}
+	}
+	return resolved.value, resolved.size, nil
+}
+
+func (r *refResolver) document(location string) (interface{}, error) {
+	if doc, ok := r.docs[location]; ok {
+		return doc, nil
+	}
+	if r.load == nil {
+		return nil, fmt.Errorf("external references need the spec to be imported from a file or URL")
+	}
+	data, err := r.load(location)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseSpecDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", location, err)
+	}
+	r.docs[location] = doc
+	return doc, nil
+}
+
+// joinLocation resolves ref against the document location it appears in,
+// which may be a URL or a file path.
+func joinLocation(base string, ref string) string {
+	if u, err := url.Parse(ref); err == nil && u.IsAbs() {
+		return ref
+	}
+	if u, err := url.Parse(base); err == nil && u.IsAbs() && len(u.Scheme) > 1 {
+		refURL, err := url.Parse(ref)
+		if err == nil {
+			return u.ResolveReference(refURL).String()
+		}
+	}
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(ref))
+}
+
+// jsonPointer looks up an RFC 6901 pointer such as /components/schemas/Pet.
+func jsonPointer(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" || pointer == "/" {
+		return doc, nil
+	}
+	if unescaped, err := url.PathUnescape(pointer); err == nil {
+		pointer = unescaped
+	}
+
+	node := doc
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := node.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+			node = v[i]
+		default:
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+	return node, nil
+}
+
+func loadSpecFile(location string) ([]byte, error) {
+	return os.ReadFile(location)
+}
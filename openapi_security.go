@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// SecurityRequirement maps security scheme names to the scopes they need. An
+// empty requirement means the operation may be called anonymously.
+type SecurityRequirement map[string][]string
+
+// operationAuth picks the auth for an operation from its own security, or the
+// spec-wide security when the operation has none. Only the first alternative
+// that can be expressed as an Auth is used, since a request carries one Auth.
+func operationAuth(spec *OpenAPISpec, operation *Operation) *Auth {
+	requirements := spec.Security
+	if operation.Security != nil {
+		requirements = *operation.Security
+	}
+	if spec.Components == nil {
+		return nil
+	}
+
+	for _, requirement := range requirements {
+		if len(requirement) == 0 {
+			return nil
+		}
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			scheme, ok := spec.Components.SecuritySchemes[name]
+			if !ok {
+				continue
+			}
+			if auth := securitySchemeAuth(name, scheme, requirement[name]); auth != nil {
+				return auth
+			}
+		}
+	}
+	return nil
+}
+
+// securitySchemeAuth maps a security scheme onto an Auth whose secrets are
+// {{variable}} placeholders named after the scheme.
+func securitySchemeAuth(name string, scheme SecurityScheme, scopes []string) *Auth {
+	variable := func(suffix string) string {
+		return "{{" + securityVariableName(name, suffix) + "}}"
+	}
+
+	switch strings.ToLower(scheme.Type) {
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "basic":
+			return &Auth{
+				Type:     AuthBasic,
+				Username: variable("username"),
+				Password: variable("password"),
+			}
+		case "digest":
+			return &Auth{
+				Type:     AuthDigest,
+				Username: variable("username"),
+				Password: variable("password"),
+			}
+		case "bearer":
+			return &Auth{
+				Type:  AuthBearer,
+				Token: variable("token"),
+			}
+		}
+	case "apikey":
+		in := strings.ToLower(scheme.In)
+		if in != "header" && in != "query" && in != "cookie" {
+			return nil
+		}
+		return &Auth{
+			Type:        AuthAPIKey,
+			ApiKeyName:  scheme.Name,
+			ApiKeyValue: variable("api_key"),
+			ApiKeyIn:    in,
+		}
+	case "oauth2":
+		auth := &Auth{
+			Type:               AuthOAuth2,
+			OAuth2ClientId:     variable("client_id"),
+			OAuth2ClientSecret: variable("client_secret"),
+		}
+
+		var flow *OAuthFlow
+		if scheme.Flows != nil {
+			switch {
+			case scheme.Flows.AuthorizationCode != nil:
+				flow = scheme.Flows.AuthorizationCode
+				auth.OAuth2GrantType = "authorization_code"
+			case scheme.Flows.ClientCredentials != nil:
+				flow = scheme.Flows.ClientCredentials
+				auth.OAuth2GrantType = "client_credentials"
+			case scheme.Flows.Password != nil:
+				flow = scheme.Flows.Password
+				auth.OAuth2GrantType = "password"
+				auth.Username = variable("username")
+				auth.Password = variable("password")
+			case scheme.Flows.Implicit != nil:
+				flow = scheme.Flows.Implicit
+				auth.OAuth2GrantType = "implicit"
+				auth.OAuth2ClientSecret = ""
+			}
+		}
+		if flow == nil {
+			return nil
+		}
+		auth.OAuth2AuthUrl = flow.AuthorizationUrl
+		auth.OAuth2TokenUrl = flow.TokenUrl
+		auth.OAuth2Scope = oauth2Scopes(scopes, flow.Scopes)
+		return auth
+	case "openidconnect":
+		if scheme.OpenIdConnectUrl == "" {
+			return nil
+		}
+		scope := oauth2Scopes(scopes, nil)
+		if !strings.Contains(" "+scope+" ", " openid ") {
+			scope = strings.TrimSpace("openid " + scope)
+		}
+		return &Auth{
+			Type:               AuthOAuth2,
+			OAuth2GrantType:    "authorization_code",
+			OAuth2Issuer:       strings.TrimSuffix(strings.TrimSuffix(scheme.OpenIdConnectUrl, "/.well-known/openid-configuration"), "/"),
+			OAuth2ClientId:     variable("client_id"),
+			OAuth2ClientSecret: variable("client_secret"),
+			OAuth2Scope:        scope,
+		}
+	}
+	return nil
+}
+
+// oauth2Scopes prefers the scopes an operation requires over every scope the
+// flow offers.
+func oauth2Scopes(required []string, available map[string]string) string {
+	if len(required) > 0 {
+		return strings.Join(required, " ")
+	}
+	scopes := make([]string, 0, len(available))
+	for scope := range available {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return strings.Join(scopes, " ")
+}
+
+var nonVariableChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// securityVariableName turns a scheme name such as "petstore-auth" into an
+// environment variable name such as "petstore_auth_token".
+func securityVariableName(scheme string, suffix string) string {
+	name := strings.Trim(nonVariableChars.ReplaceAllString(scheme, "_"), "_")
+	if name == "" {
+		return suffix
+	}
+	return name + "_" + suffix
+}
+
+// openAPISecurityVariables lists the variables referenced by the auth of the
+// imported requests, for the environment skeleton created on import.
+func openAPISecurityVariables(spec *OpenAPISpec) []string {
+	if spec.Components == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var vars []string
+	for _, pathItem := range spec.Paths {
+		for _, operation := range pathItem.operations() {
+			auth := operationAuth(spec, operation)
+			if auth == nil {
+				continue
+			}
+			for _, field := range []string{auth.Username, auth.Password, auth.Token, auth.ApiKeyValue, auth.OAuth2ClientId, auth.OAuth2ClientSecret} {
+				name := strings.TrimSuffix(strings.TrimPrefix(field, "{{"), "}}")
+				if name == field || seen[name] {
+					continue
+				}
+				seen[name] = true
+				vars = append(vars, name)
+			}
+		}
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+// saveOpenAPIEnvironment creates an environment named after the spec listing
+// the secrets its auth needs, with empty values for the user to fill in. On
+// re-import, variables already present keep their values.
+func (a *App) saveOpenAPIEnvironment(spec *OpenAPISpec) error {
+	vars := openAPISecurityVariables(spec)
+	if len(vars) == 0 {
+		return nil
+	}
+
+	name := spec.Info.Title
+	if name == "" {
+		name = "OpenAPI"
+	}
+
+	env := Environment{ID: uuid.New().String(), Name: name, Variables: make(map[string]string)}
+	for _, existing := range a.environmentStorage.GetAllEnvironments() {
+		if existing.Name == name {
+			env = existing
+			variables := make(map[string]string, len(existing.Variables))
+			for k, v := range existing.Variables {
+				variables[k] = v
+			}
+			env.Variables = variables
+			break
+		}
+	}
+
+	changed := false
+	for _, v := range vars {
+		if _, ok := env.Variables[v]; !ok {
+			env.Variables[v] = ""
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if err := a.environmentStorage.SaveEnvironment(env); err != nil {
+		return fmt.Errorf("failed to save environment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// defaultOpenAPIPath is where our services publish their spec.
+	defaultOpenAPIPath = "/openapi.json"
+
+	openAPIURLWatchInterval = 30 * time.Second
+	maxSpecFileSize         = 32 << 20
+)
+
+// specRootNames are the conventional names of the entry file of a spec split
+// across several files, in order of preference.
+var specRootNames = []string{
+	"openapi.yaml", "openapi.yml", "openapi.json",
+	"swagger.yaml", "swagger.yml", "swagger.json",
+	"api.yaml", "api.yml", "api.json",
+}
+
+func isSpecFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// ParseOpenAPIURL downloads a spec and the files it references. headers are
+// sent only to the host of specURL so credentials don't leak to other hosts.
+func ParseOpenAPIURL(specURL string, headers []KeyValue) (*OpenAPISpec, error) {
+	load := openAPIURLLoader(specURL, headers)
+	data, err := load(specURL)
+	if err != nil {
+		return nil, err
+	}
+	return parseOpenAPIDocument(newRefResolver(load), data, specURL)
+}
+
+func openAPIURLLoader(specURL string, headers []KeyValue) func(location string) ([]byte, error) {
+	origin, _ := url.Parse(specURL)
+	client := openAPIClient(headers)
+
+	return func(location string) ([]byte, error) {
+		target, err := url.Parse(location)
+		if err != nil || target.Scheme != "http" && target.Scheme != "https" {
+			return nil, fmt.Errorf("unsupported spec location: %s", location)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json, application/yaml, text/yaml, */*")
+		if origin != nil && strings.EqualFold(target.Host, origin.Host) {
+			for _, h := range headers {
+				if h.Enabled && h.Key != "" {
+					req.Header.Set(h.Key, h.Value)
+				}
+			}
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", location, err)
+		}
+		defer resp.Body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxSpecFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", location, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch %s: status %d", location, resp.StatusCode)
+		}
+		return data, nil
+	}
+}
+
+// openAPIClient follows redirects like the default client but drops the
+// caller's headers once a redirect leaves the host they were sent to. The
+// default client only strips Authorization and Cookie, not custom headers
+// such as an API key.
+func openAPIClient(headers []KeyValue) *http.Client {
+	return &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+				for _, h := range headers {
+					req.Header.Del(h.Key)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// ParseOpenAPIPath parses a spec file, a directory holding a spec split across
+// several files, or a zip archive of such a directory.
+func ParseOpenAPIPath(specPath string) (*OpenAPISpec, error) {
+	info, err := os.Stat(specPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		root, err := findSpecRootInDir(specPath)
+		if err != nil {
+			return nil, err
+		}
+		return ParseOpenAPIFile(root)
+	}
+	if strings.EqualFold(filepath.Ext(specPath), ".zip") {
+		return parseOpenAPIZip(specPath)
+	}
+	return ParseOpenAPIFile(specPath)
+}
+
+func findSpecRootInDir(dir string) (string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isSpecFile(d.Name()) {
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	root := pickSpecRoot(names, func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+	if root == "" {
+		return "", fmt.Errorf("no OpenAPI or Swagger document found in %s", dir)
+	}
+	return filepath.Join(dir, filepath.FromSlash(root)), nil
+}
+
+// pickSpecRoot chooses the entry file among slash-separated names: the
+// shallowest file with a conventional name, otherwise the shallowest file that
+// declares an openapi or swagger version.
+func pickSpecRoot(names []string, read func(name string) ([]byte, error)) string {
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := strings.Count(names[i], "/"), strings.Count(names[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		base := strings.ToLower(path.Base(name))
+		for _, rootName := range specRootNames {
+			if base == rootName {
+				return name
+			}
+		}
+	}
+
+	for _, name := range names {
+		data, err := read(name)
+		if err != nil {
+			continue
+		}
+		doc, err := parseSpecDocument(data)
+		if err != nil {
+			continue
+		}
+		if root, ok := doc.(map[string]interface{}); ok && (root["openapi"] != nil || root["swagger"] != nil) {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseOpenAPIZip parses a spec from a zip archive, resolving relative $refs
+// between the files inside it.
+func parseOpenAPIZip(zipPath string) (*OpenAPISpec, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	files := make(map[string][]byte)
+	var names []string
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || !isSpecFile(file.Name) || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		if file.UncompressedSize64 > maxSpecFileSize {
+			return nil, fmt.Errorf("%s is too large", file.Name)
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, maxSpecFileSize))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		name := path.Clean(file.Name)
+		files[name] = data
+		names = append(names, name)
+	}
+
+	read := func(location string) ([]byte, error) {
+		data, ok := files[path.Clean(filepath.ToSlash(location))]
+		if !ok {
+			return nil, fmt.Errorf("%s not found in archive", location)
+		}
+		return data, nil
+	}
+
+	root := pickSpecRoot(names, read)
+	if root == "" {
+		return nil, fmt.Errorf("no OpenAPI or Swagger document found in %s", zipPath)
+	}
+	return parseOpenAPIDocument(newRefResolver(read), files[root], filepath.FromSlash(root))
+}
+
+// readSpecSource returns the content a watch compares between polls: the
+// file itself, or every spec file of a directory.
+func readSpecSource(specPath string) ([]byte, error) {
+	info, err := os.Stat(specPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return os.ReadFile(specPath)
+	}
+
+	var content []byte
+	err = filepath.WalkDir(specPath, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isSpecFile(d.Name()) {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		content = append(content, p...)
+		content = append(content, data...)
+		return nil
+	})
+	return content, err
+}
+
+// openAPIURL resolves the URL a spec is imported from. A path such as
+// "/openapi.json", or an empty URL, is relative to the project's base URL,
+// and a bare service URL gets the default spec path.
+func (a *App) openAPIURL(specURL string, projectId string) (string, error) {
+	specURL = strings.TrimSpace(a.ReplaceVariables(specURL))
+	if specURL == "" || strings.HasPrefix(specURL, "/") {
+		project := a.projectStorage.GetProject(projectId)
+		if project == nil || project.BaseUrl == "" {
+			return "", fmt.Errorf("a full spec URL is required when the project has no base URL")
+		}
+		if specURL == "" {
+			specURL = defaultOpenAPIPath
+		}
+		specURL = strings.TrimSuffix(a.ReplaceVariables(project.BaseUrl), "/") + specURL
+	}
+
+	u, err := url.Parse(specURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid spec URL: %s", specURL)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = defaultOpenAPIPath
+	}
+	return u.String(), nil
+}
+
+func (a *App) openAPIHeaders(headers []KeyValue) []KeyValue {
+	resolved := make([]KeyValue, len(headers))
+	for i, h := range headers {
+		h.Value = a.ReplaceVariables(h.Value)
+		resolved[i] = h
+	}
+	return resolved
+}
+
+// ImportOpenAPIFromURL imports the spec published at specURL. headers, such as
+// an Authorization header for a private portal, may use {{variables}}.
+func (a *App) ImportOpenAPIFromURL(specURL string, headers []KeyValue, projectId string) ([]HttpRequest, error) {
+	target, err := a.openAPIURL(specURL, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := ParseOpenAPIURL(target, a.openAPIHeaders(headers))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI file: %w", err)
+	}
+
+	_, requests, err := a.syncOpenAPISpec(spec, projectId, OpenAPISyncOptions{})
+	return requests, err
+}
+
+// SyncOpenAPIURL is SyncOpenAPI for a spec published at a URL.
+func (a *App) SyncOpenAPIURL(specURL string, headers []KeyValue, projectId string, options OpenAPISyncOptions) (*OpenAPISyncDiff, error) {
+	target, err := a.openAPIURL(specURL, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := ParseOpenAPIURL(target, a.openAPIHeaders(headers))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI file: %w", err)
+	}
+
+	diff, _, err := a.syncOpenAPISpec(spec, projectId, options)
+	return diff, err
+}
+
+// WatchOpenAPIURL polls the spec at specURL and re-syncs the project when it
+// changes, until UnwatchOpenAPI is called.
+func (a *App) WatchOpenAPIURL(specURL string, headers []KeyValue, projectId string, options OpenAPISyncOptions) error {
+	target, err := a.openAPIURL(specURL, projectId)
+	if err != nil {
+		return err
+	}
+
+	headers = a.openAPIHeaders(headers)
+	fetch := openAPIURLLoader(target, headers)
+	load := func() ([]byte, error) {
+		return fetch(target)
+	}
+	parse := func(data []byte) (*OpenAPISpec, error) {
+		return parseOpenAPIDocument(newRefResolver(fetch), data, target)
+	}
+	if err := a.watchOpenAPI(projectId, target, openAPIURLWatchInterval, load, parse, options); err != nil {
+		return fmt.Errorf("failed to watch OpenAPI URL: %w", err)
+	}
+	return nil
+}
+
+// ImportOpenAPIDirectory imports a spec split across the files of a
+// directory. An empty dirPath asks the user to pick the directory.
+func (a *App) ImportOpenAPIDirectory(dirPath string, projectId string) ([]HttpRequest, error) {
+	if dirPath == "" {
+		path, err := a.SelectOpenAPIDirectory()
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			return nil, nil
+		}
+		dirPath = path
+	}
+
+	return a.ImportOpenAPIFile(dirPath, projectId, "")
+}
+
+// SelectOpenAPIFile asks the user for a spec file or zip archive, returning ""
+// when the dialog is cancelled.
+func (a *App) SelectOpenAPIFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import OpenAPI",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "OpenAPI Files", Pattern: "*.json;*.yaml;*.yml;*.zip"},
+		},
+	})
+}
+
+// SelectOpenAPIDirectory asks the user for a directory holding a spec split
+// across several files, returning "" when the dialog is cancelled.
+func (a *App) SelectOpenAPIDirectory() (string, error) {
+	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import OpenAPI Directory",
+	})
+}
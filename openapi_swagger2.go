@@ -0,0 +1,351 @@
+package main
+
+import (
+	"strings"
+)
+
+var swaggerOperationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// convertSwagger2 rewrites a resolved Swagger 2.0 document into the shape of
+// an OpenAPI 3.0 document so it can be decoded into OpenAPISpec:
+// host/schemes become servers, basePath is prefixed onto the paths since
+// requests keep only the path, body and formData parameters become request
+// bodies, and definitions/securityDefinitions move under components.
+func convertSwagger2(doc map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{
+		"openapi": "3.0.0",
+		"swagger": doc["swagger"],
+	}
+	if info, ok := doc["info"]; ok {
+		out["info"] = info
+	}
+	if security, ok := doc["security"]; ok {
+		out["security"] = security
+	}
+	if server := swaggerServerURL(doc); server != "" {
+		out["servers"] = []interface{}{map[string]interface{}{"url": server}}
+	}
+
+	basePath, _ := doc["basePath"].(string)
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	consumes := stringList(doc["consumes"])
+	produces := stringList(doc["produces"])
+
+	paths := make(map[string]interface{})
+	if swaggerPaths, ok := doc["paths"].(map[string]interface{}); ok {
+		for path, rawItem := range swaggerPaths {
+			item, ok := rawItem.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			pathParams := mapList(item["parameters"])
+
+			newItem := make(map[string]interface{})
+			var sharedParams []interface{}
+			for _, param := range pathParams {
+				if in := param["in"]; in != "body" && in != "formData" {
+					sharedParams = append(sharedParams, convertSwaggerParameter(param))
+				}
+			}
+			if len(sharedParams) > 0 {
+				newItem["parameters"] = sharedParams
+			}
+
+			for _, method := range swaggerOperationMethods {
+				op, ok := item[method].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				newItem[method] = convertSwaggerOperation(op, pathParams, consumes, produces)
+			}
+			paths[basePath+path] = newItem
+		}
+	}
+	out["paths"] = paths
+
+	components := make(map[string]interface{})
+	if definitions, ok := doc["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = definitions
+	}
+	if params, ok := doc["parameters"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{})
+		for name, raw := range params {
+			if param, ok := raw.(map[string]interface{}); ok && param["in"] != "body" && param["in"] != "formData" {
+				converted[name] = convertSwaggerParameter(param)
+			}
+		}
+		components["parameters"] = converted
+	}
+	if definitions, ok := doc["securityDefinitions"].(map[string]interface{}); ok {
+		schemes := make(map[string]interface{})
+		for name, raw := range definitions {
+			if definition, ok := raw.(map[string]interface{}); ok {
+				schemes[name] = convertSwaggerSecurity(definition)
+			}
+		}
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		out["components"] = components
+	}
+
+	return out
+}
+
+// swaggerServerURL builds the server URL from schemes and host. A spec without
+// host is served from wherever it was loaded and gets no server.
+func swaggerServerURL(doc map[string]interface{}) string {
+	host, _ := doc["host"].(string)
+	if host == "" {
+		return ""
+	}
+
+	scheme := "https"
+	if schemes := stringList(doc["schemes"]); len(schemes) > 0 {
+		scheme = schemes[0]
+		for _, s := range schemes {
+			if s == "https" {
+				scheme = s
+				break
+			}
+		}
+	}
+	return scheme + "://" + host
+}
+
+func convertSwaggerOperation(op map[string]interface{}, pathParams []map[string]interface{}, consumes []string, produces []string) map[string]interface{} {
+	out := make(map[string]interface{})
+	for key, value := range op {
+		switch key {
+		case "parameters", "responses", "consumes", "produces", "schemes":
+		default:
+			out[key] = value
+		}
+	}
+	if opConsumes := stringList(op["consumes"]); len(opConsumes) > 0 {
+		consumes = opConsumes
+	}
+	if opProduces := stringList(op["produces"]); len(opProduces) > 0 {
+		produces = opProduces
+	}
+
+	// Operation parameters override path parameters with the same name and location.
+	opParams := mapList(op["parameters"])
+	params := make([]map[string]interface{}, 0, len(pathParams)+len(opParams))
+	for _, param := range pathParams {
+		overridden := false
+		for _, opParam := range opParams {
+			if opParam["name"] == param["name"] && opParam["in"] == param["in"] {
+				overridden = true
+				break
+			}
+		}
+		if !overridden && (param["in"] == "body" || param["in"] == "formData") {
+			params = append(params, param)
+		}
+	}
+	params = append(params, opParams...)
+
+	var parameters []interface{}
+	var body map[string]interface{}
+	formProperties := make(map[string]interface{})
+	var formRequired []interface{}
+	hasFile := false
+	for _, param := range params {
+		switch param["in"] {
+		case "body":
+			body = param
+		case "formData":
+			name, _ := param["name"].(string)
+			schema := swaggerParameterSchema(param)
+			if param["type"] == "file" {
+				hasFile = true
+			}
+			if description, ok := param["description"]; ok {
+				schema["description"] = description
+			}
+			formProperties[name] = schema
+			if required, _ := param["required"].(bool); required {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			parameters = append(parameters, convertSwaggerParameter(param))
+		}
+	}
+	if len(parameters) > 0 {
+		out["parameters"] = parameters
+	}
+
+	if body != nil {
+		mediaType := map[string]interface{}{}
+		if schema, ok := body["schema"]; ok {
+			mediaType["schema"] = schema
+		}
+		if example, ok := body["x-example"]; ok {
+			mediaType["example"] = example
+		}
+		contentTypes := consumes
+		if len(contentTypes) == 0 {
+			contentTypes = []string{"application/json"}
+		}
+		content := make(map[string]interface{})
+		for _, contentType := range contentTypes {
+			if !strings.Contains(contentType, "form") {
+				content[contentType] = mediaType
+			}
+		}
+		if len(content) == 0 {
+			content["application/json"] = mediaType
+		}
+		requestBody := map[string]interface{}{"content": content}
+		if description, ok := body["description"]; ok {
+			requestBody["description"] = description
+		}
+		if required, ok := body["required"]; ok {
+			requestBody["required"] = required
+		}
+		out["requestBody"] = requestBody
+	} else if len(formProperties) > 0 {
+		contentType := "application/x-www-form-urlencoded"
+		if hasFile || containsString(consumes, "multipart/form-data") {
+			contentType = "multipart/form-data"
+		}
+		schema := map[string]interface{}{"type": "object", "properties": formProperties}
+		if len(formRequired) > 0 {
+			schema["required"] = formRequired
+		}
+		out["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{contentType: map[string]interface{}{"schema": schema}},
+		}
+	}
+
+	if responses, ok := op["responses"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(responses))
+		for code, raw := range responses {
+			response, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			newResponse := map[string]interface{}{"description": response["description"]}
+			if schema, ok := response["schema"]; ok {
+				contentType := "application/json"
+				if len(produces) > 0 {
+					contentType = produces[0]
+				}
+				mediaType := map[string]interface{}{"schema": schema}
+				if examples, ok := response["examples"].(map[string]interface{}); ok {
+					if example, ok := examples[contentType]; ok {
+						mediaType["example"] = example
+					}
+				}
+				newResponse["content"] = map[string]interface{}{contentType: mediaType}
+			}
+			converted[code] = newResponse
+		}
+		out["responses"] = converted
+	}
+
+	return out
+}
+
+// convertSwaggerParameter turns a query, header or path parameter, whose
+// type fields sit on the parameter itself in 2.0, into one with a schema.
+func convertSwaggerParameter(param map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	for _, key := range []string{"name", "in", "description", "required"} {
+		if value, ok := param[key]; ok {
+			out[key] = value
+		}
+	}
+	out["schema"] = swaggerParameterSchema(param)
+	if example, ok := param["x-example"]; ok {
+		out["example"] = example
+	}
+	return out
+}
+
+func swaggerParameterSchema(param map[string]interface{}) map[string]interface{} {
+	schema := make(map[string]interface{})
+	for _, key := range []string{"type", "format", "items", "enum", "default", "minimum", "example"} {
+		if value, ok := param[key]; ok {
+			schema[key] = value
+		}
+	}
+	if example, ok := param["x-example"]; ok {
+		schema["example"] = example
+	}
+	if schema["type"] == "file" {
+		schema["type"] = "string"
+		schema["format"] = "binary"
+	}
+	return schema
+}
+
+func convertSwaggerSecurity(definition map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	if description, ok := definition["description"]; ok {
+		out["description"] = description
+	}
+
+	switch definition["type"] {
+	case "basic":
+		out["type"] = "http"
+		out["scheme"] = "basic"
+	case "apiKey":
+		out["type"] = "apiKey"
+		out["name"] = definition["name"]
+		out["in"] = definition["in"]
+	case "oauth2":
+		out["type"] = "oauth2"
+		flow := map[string]interface{}{}
+		for _, key := range []string{"authorizationUrl", "tokenUrl", "scopes"} {
+			if value, ok := definition[key]; ok {
+				flow[key] = value
+			}
+		}
+		flowName := map[interface{}]string{
+			"implicit":    "implicit",
+			"password":    "password",
+			"application": "clientCredentials",
+			"accessCode":  "authorizationCode",
+		}[definition["flow"]]
+		if flowName != "" {
+			out["flows"] = map[string]interface{}{flowName: flow}
+		}
+	default:
+		out["type"] = definition["type"]
+	}
+	return out
+}
+
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func mapList(value interface{}) []map[string]interface{} {
+	items, _ := value.([]interface{})
+	list := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
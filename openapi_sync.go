@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OpenAPISyncOptions controls how an import is merged into a project.
+type OpenAPISyncOptions struct {
+	RemoveMissing bool `json:"removeMissing"` // delete requests whose operation left the spec
+	DryRun        bool `json:"dryRun"`        // only compute the diff
+	AdoptMatching bool `json:"adoptMatching"` // take over requests with no import record that match an operation's method and URL
+}
+
+// OpenAPISyncDiff describes what an import changes in a project.
+type OpenAPISyncDiff struct {
+	Added     []OpenAPIOperationDiff `json:"added"`
+	Removed   []OpenAPIOperationDiff `json:"removed"`
+	Changed   []OpenAPIOperationDiff `json:"changed"`
+	Adopted   []OpenAPIOperationDiff `json:"adopted"` // matched by method and URL only; merged with AdoptMatching, added as new otherwise
+	Unchanged int                    `json:"unchanged"`
+}
+
+type OpenAPIOperationDiff struct {
+	OperationKey string   `json:"operationKey"`
+	RequestId    string   `json:"requestId,omitempty"`
+	Name         string   `json:"name"`
+	Method       string   `json:"method"`
+	Path         string   `json:"path"`
+	Changes      []string `json:"changes,omitempty"` // signature lines prefixed with + or -
+}
+
+// requestSignature lists the parts of an imported request that come from the
+// spec, one "kind:value" line each, so two imports can be compared.
+func requestSignature(req HttpRequest) []string {
+	lines := []string{
+		"method:" + string(req.Method),
+		"url:" + req.URL,
+		"name:" + req.Name,
+	}
+	for _, h := range req.Headers {
+		lines = append(lines, "header:"+h.Key)
+	}
+	for _, p := range req.Params {
+		lines = append(lines, "query:"+p.Key)
+	}
+	for _, p := range req.PathParams {
+		lines = append(lines, "path:"+p.Key)
+	}
+	if req.Body != nil {
+		lines = append(lines, "body:"+req.Body.Type)
+		for _, f := range req.Body.FormData {
+			lines = append(lines, "form:"+f.Key)
+		}
+	}
+	if req.Auth != nil {
+		lines = append(lines, "auth:"+string(req.Auth.Type))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func signatureValue(signature []string, kind string) (string, bool) {
+	for _, line := range signature {
+		if value, ok := strings.CutPrefix(line, kind+":"); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func signatureKeys(signature []string, kind string) map[string]bool {
+	keys := make(map[string]bool)
+	for _, line := range signature {
+		if value, ok := strings.CutPrefix(line, kind+":"); ok {
+			keys[value] = true
+		}
+	}
+	return keys
+}
+
+func signatureChanges(old []string, new []string) []string {
+	oldSet := make(map[string]bool, len(old))
+	for _, line := range old {
+		oldSet[line] = true
+	}
+	newSet := make(map[string]bool, len(new))
+	for _, line := range new {
+		newSet[line] = true
+	}
+
+	var changes []string
+	for _, line := range old {
+		if !newSet[line] {
+			changes = append(changes, "-"+line)
+		}
+	}
+	for _, line := range new {
+		if !oldSet[line] {
+			changes = append(changes, "+"+line)
+		}
+	}
+	return changes
+}
+
+func operationDiff(req HttpRequest, key string) OpenAPIOperationDiff {
+	return OpenAPIOperationDiff{
+		OperationKey: key,
+		RequestId:    req.ID,
+		Name:         req.Name,
+		Method:       string(req.Method),
+		Path:         req.URL,
+	}
+}
+
+// planOpenAPISync matches generated requests with the existing requests of a
+// project by operation key. Requests without an import record, whether
+// imported before keys were recorded or written by hand, that share an
+// operation's method and URL are reported as adopted and merged only when
+// adopt is set; otherwise the operation is added as a new request. It
+// returns the diff, the requests to save and the IDs of requests whose
+// operations are gone.
+func planOpenAPISync(existing []HttpRequest, generated []HttpRequest, adopt bool) (*OpenAPISyncDiff, []HttpRequest, []string) {
+	byKey := make(map[string]int)
+	byRoute := make(map[string]int)
+	for i, req := range existing {
+		if req.OpenAPI != nil {
+			byKey[req.OpenAPI.OperationKey] = i
+		} else {
+			byRoute[string(req.Method)+" "+req.URL] = i
+		}
+	}
+
+	diff := &OpenAPISyncDiff{
+		Added:   []OpenAPIOperationDiff{},
+		Removed: []OpenAPIOperationDiff{},
+		Changed: []OpenAPIOperationDiff{},
+		Adopted: []OpenAPIOperationDiff{},
+	}
+	var merged []HttpRequest
+	matched := make(map[int]bool)
+
+	for _, gen := range generated {
+		key := gen.OpenAPI.OperationKey
+		if i, ok := byKey[key]; ok && !matched[i] {
+			matched[i] = true
+			current := existing[i]
+			changes := signatureChanges(current.OpenAPI.Signature, gen.OpenAPI.Signature)
+			if len(changes) == 0 {
+				diff.Unchanged++
+				continue
+			}
+
+			entry := operationDiff(current, key)
+			entry.Changes = changes
+			diff.Changed = append(diff.Changed, entry)
+			merged = append(merged, mergeImportedRequest(current, gen, current.OpenAPI.Signature))
+			continue
+		}
+
+		if i, ok := byRoute[string(gen.Method)+" "+gen.URL]; ok && !matched[i] {
+			current := existing[i]
+			entry := operationDiff(current, key)
+			entry.Changes = signatureChanges(requestSignature(current), gen.OpenAPI.Signature)
+			diff.Adopted = append(diff.Adopted, entry)
+			if adopt {
+				// A request without an import record has no trustworthy
+				// signature, so nothing is dropped from it.
+				matched[i] = true
+				merged = append(merged, mergeImportedRequest(current, gen, nil))
+			} else {
+				merged = append(merged, gen)
+			}
+			continue
+		}
+
+		diff.Added = append(diff.Added, operationDiff(gen, key))
+		merged = append(merged, gen)
+	}
+
+	var removed []string
+	for i, req := range existing {
+		if req.OpenAPI != nil && !matched[i] {
+			diff.Removed = append(diff.Removed, operationDiff(req, req.OpenAPI.OperationKey))
+			removed = append(removed, req.ID)
+		}
+	}
+
+	return diff, merged, removed
+}
+
+// mergeImportedRequest updates current with what the spec now generates while
+// keeping the user's edits: values, user-added headers and params, scripts,
+// settings, an edited URL, a renamed title and an edited body or auth.
+// oldSignature is what the spec generated last time; keys listed there but no
+// longer generated were removed from the spec and are dropped.
+func mergeImportedRequest(current HttpRequest, gen HttpRequest, oldSignature []string) HttpRequest {
+	merged := current
+	merged.Method = gen.Method
+	merged.OpenAPI = gen.OpenAPI
+
+	if oldURL, ok := signatureValue(oldSignature, "url"); ok && current.URL == oldURL {
+		merged.URL = gen.URL
+	}
+	if oldName, ok := signatureValue(oldSignature, "name"); ok && current.Name == oldName {
+		merged.Name = gen.Name
+	}
+
+	merged.Headers = mergeKeyValues(current.Headers, gen.Headers, signatureKeys(oldSignature, "header"), true)
+	merged.Params = mergeKeyValues(current.Params, gen.Params, signatureKeys(oldSignature, "query"), false)
+	merged.PathParams = mergeKeyValues(current.PathParams, gen.PathParams, signatureKeys(oldSignature, "path"), false)
+
+	oldBodyType, _ := signatureValue(oldSignature, "body")
+	switch {
+	case current.Body == nil || current.Body.Content == "" && len(current.Body.FormData) == 0 && current.Body.FilePath == "":
+		merged.Body = gen.Body
+	case gen.Body != nil && oldBodyType != "" && oldBodyType != gen.Body.Type:
+		merged.Body = gen.Body
+	case gen.Body != nil:
+		body := *current.Body
+		body.FormData = mergeKeyValues(current.Body.FormData, gen.Body.FormData, signatureKeys(oldSignature, "form"), false)
+		merged.Body = &body
+	}
+
+	oldAuthType, _ := signatureValue(oldSignature, "auth")
+	newAuthType, _ := signatureValue(gen.OpenAPI.Signature, "auth")
+	if current.Auth == nil || oldAuthType != newAuthType && current.Auth.Type == AuthType(oldAuthType) {
+		merged.Auth = gen.Auth
+	}
+
+	return merged
+}
+
+// mergeKeyValues keeps the current entries, with their values, for keys the
+// spec still generates or that the user added, drops keys the spec removed
+// and appends keys the spec added.
+func mergeKeyValues(current []KeyValue, generated []KeyValue, oldKeys map[string]bool, caseInsensitive bool) []KeyValue {
+	normalize := func(key string) string {
+		if caseInsensitive {
+			return strings.ToLower(key)
+		}
+		return key
+	}
+
+	generatedKeys := make(map[string]bool, len(generated))
+	for _, kv := range generated {
+		generatedKeys[normalize(kv.Key)] = true
+	}
+	removedKeys := make(map[string]bool, len(oldKeys))
+	for key := range oldKeys {
+		if !generatedKeys[normalize(key)] {
+			removedKeys[normalize(key)] = true
+		}
+	}
+
+	merged := make([]KeyValue, 0, len(current)+len(generated))
+	present := make(map[string]bool, len(current))
+	for _, kv := range current {
+		key := normalize(kv.Key)
+		if removedKeys[key] {
+			continue
+		}
+		merged = append(merged, kv)
+		present[key] = true
+	}
+	for _, kv := range generated {
+		if !present[normalize(kv.Key)] {
+			merged = append(merged, kv)
+		}
+	}
+	return merged
+}
+
+// syncOpenAPISpec merges the requests generated from spec into a project.
+// It returns the diff and the project's imported requests after the merge.
+func (a *App) syncOpenAPISpec(spec *OpenAPISpec, projectId string, options OpenAPISyncOptions) (*OpenAPISyncDiff, []HttpRequest, error) {
+	generated := ConvertOpenAPIToRequests(spec, projectId, "")
+	diff, merged, removed := planOpenAPISync(a.requestStorage.GetProjectRequests(projectId), generated, options.AdoptMatching)
+	if options.DryRun {
+		return diff, nil, nil
+	}
+
+	if len(merged) > 0 {
+		if err := a.requestStorage.AddRequests(merged); err != nil {
+			return nil, nil, fmt.Errorf("failed to save requests: %w", err)
+		}
+	}
+	if options.RemoveMissing && len(removed) > 0 {
+		if err := a.requestStorage.DeleteRequests(projectId, removed); err != nil {
+			return nil, nil, fmt.Errorf("failed to delete requests: %w", err)
+		}
+	}
+
+	if err := a.saveOpenAPIEnvironment(spec); err != nil {
+		return nil, nil, err
+	}
+
+	var requests []HttpRequest
+	for _, req := range a.requestStorage.GetProjectRequests(projectId) {
+		if req.OpenAPI != nil {
+			requests = append(requests, req)
+		}
+	}
+	return diff, requests, nil
+}
+
+// SyncOpenAPI re-imports a spec into a project, updating the requests that
+// came from earlier imports instead of duplicating them. With DryRun set it
+// only reports the diff.
+func (a *App) SyncOpenAPI(fileContent string, format string, projectId string, options OpenAPISyncOptions) (*OpenAPISyncDiff, error) {
+	spec, err := ParseOpenAPI([]byte(fileContent), format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI file: %w", err)
+	}
+
+	diff, _, err := a.syncOpenAPISpec(spec, projectId, options)
+	return diff, err
+}
+
+// SyncOpenAPIFile is SyncOpenAPI for a spec file, directory or zip archive.
+func (a *App) SyncOpenAPIFile(filePath string, projectId string, options OpenAPISyncOptions) (*OpenAPISyncDiff, error) {
+	spec, err := ParseOpenAPIPath(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI file: %w", err)
+	}
+
+	diff, _, err := a.syncOpenAPISpec(spec, projectId, options)
+	return diff, err
+}
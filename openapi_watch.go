@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const openAPIFileWatchInterval = 2 * time.Second
+
+// OpenAPIWatchEvent is emitted as openapi:changed whenever a watched spec
+// changes. Applied is false for DryRun watches, which only report the diff.
+type OpenAPIWatchEvent struct {
+	ProjectId string           `json:"projectId"`
+	Source    string           `json:"source"`
+	Diff      *OpenAPISyncDiff `json:"diff,omitempty"`
+	Applied   bool             `json:"applied"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// openAPIWatches tracks the spec watched for each project; watching a new
+// source replaces the previous one.
+type openAPIWatches struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newOpenAPIWatches() *openAPIWatches {
+	return &openAPIWatches{
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+func (w *openAPIWatches) start(parent context.Context, projectId string) context.Context {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	w.mu.Lock()
+	if previous, ok := w.cancels[projectId]; ok {
+		previous()
+	}
+	w.cancels[projectId] = cancel
+	w.mu.Unlock()
+
+	return ctx
+}
+
+func (w *openAPIWatches) stop(projectId string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if cancel, ok := w.cancels[projectId]; ok {
+		cancel()
+		delete(w.cancels, projectId)
+	}
+}
+
+// watchOpenAPI polls a spec source and syncs the project whenever its content
+// changes. load fetches the raw spec; parse turns it into a spec.
+func (a *App) watchOpenAPI(projectId string, source string, interval time.Duration, load func() ([]byte, error), parse func([]byte) (*OpenAPISpec, error), options OpenAPISyncOptions) error {
+	data, err := load()
+	if err != nil {
+		return err
+	}
+	last := sha256.Sum256(data)
+
+	ctx := a.openAPIWatches.start(a.ctx, projectId)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			data, err := load()
+			if err != nil {
+				a.emitEvent("openapi:changed", OpenAPIWatchEvent{ProjectId: projectId, Source: source, Error: err.Error()})
+				continue
+			}
+			sum := sha256.Sum256(data)
+			if sum == last {
+				continue
+			}
+			last = sum
+
+			event := OpenAPIWatchEvent{ProjectId: projectId, Source: source, Applied: !options.DryRun}
+			spec, err := parse(data)
+			if err == nil {
+				event.Diff, _, err = a.syncOpenAPISpec(spec, projectId, options)
+			}
+			if err != nil {
+				event.Applied = false
+				event.Error = err.Error()
+			}
+			a.emitEvent("openapi:changed", event)
+		}
+	}()
+
+	return nil
+}
+
+// WatchOpenAPIFile re-syncs the project whenever the spec file, or a spec file
+// in the watched directory, changes, until UnwatchOpenAPI is called. With
+// DryRun set changes are only reported.
+func (a *App) WatchOpenAPIFile(filePath string, projectId string, options OpenAPISyncOptions) error {
+	load := func() ([]byte, error) {
+		return readSpecSource(filePath)
+	}
+	parse := func([]byte) (*OpenAPISpec, error) {
+		return ParseOpenAPIPath(filePath)
+	}
+	if err := a.watchOpenAPI(projectId, filePath, openAPIFileWatchInterval, load, parse, options); err != nil {
+		return fmt.Errorf("failed to watch OpenAPI file: %w", err)
+	}
+	return nil
+}
+
+func (a *App) UnwatchOpenAPI(projectId string) {
+	a.openAPIWatches.stop(projectId)
+}
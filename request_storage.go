@@ -57,6 +57,9 @@ func (s *RequestStorage) AddRequest(req HttpRequest) error {
 	return s.save()
 }
 
+// AddRequests upserts requests by ID within their project, so a request whose
+// ID another project also uses, such as the legacy "req-0" IDs, never replaces
+// that project's request.
 func (s *RequestStorage) AddRequests(reqs []HttpRequest) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -64,7 +67,7 @@ func (s *RequestStorage) AddRequests(reqs []HttpRequest) error {
 	for _, req := range reqs {
 		found := false
 		for i, r := range s.requests {
-			if r.ID == req.ID {
+			if r.ID == req.ID && r.ProjectId == req.ProjectId {
 				s.requests[i] = req
 				found = true
 				break
@@ -104,6 +107,27 @@ func (s *RequestStorage) DeleteRequest(id string) error {
 	return nil
 }
 
+// DeleteRequests removes the given requests of one project. Like AddRequests it
+// matches on the project as well, since legacy IDs repeat across projects.
+func (s *RequestStorage) DeleteRequests(projectId string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	kept := s.requests[:0]
+	for _, req := range s.requests {
+		if req.ProjectId != projectId || !remove[req.ID] {
+			kept = append(kept, req)
+		}
+	}
+	s.requests = kept
+	return s.save()
+}
+
 func (s *RequestStorage) load() error {
 	data, err := os.ReadFile(s.filePath)
 	if err != nil {
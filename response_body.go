@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	BodyEncodingText   = "text"
+	BodyEncodingBase64 = "base64"
+)
+
+type ResponsePreview struct {
+	Kind     string `json:"kind"` // "image" or "pdf"
+	MimeType string `json:"mimeType"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+}
+
+// decodeResponseBody turns the raw response bytes into the string sent to the
+// frontend. Text bodies are transcoded to UTF-8 from their declared charset,
+// everything else is base64 encoded so it survives the JSON bridge intact.
+func decodeResponseBody(raw []byte, contentTypeHeader string, resp *HttpResponse) {
+	mediaType, params, err := mime.ParseMediaType(contentTypeHeader)
+	if err != nil || mediaType == "" {
+		mediaType, params, _ = mime.ParseMediaType(http.DetectContentType(raw))
+	}
+	mediaType = strings.ToLower(mediaType)
+
+	resp.ContentType = mediaType
+	resp.Charset = strings.ToLower(params["charset"])
+	resp.Preview = buildPreview(raw, mediaType)
+
+	if isTextMediaType(mediaType) {
+		if text, ok := decodeText(raw, resp.Charset, contentTypeHeader); ok {
+			resp.Body = text
+			resp.BodyEncoding = BodyEncodingText
+			return
+		}
+	}
+
+	resp.IsBinary = true
+	resp.BodyEncoding = BodyEncodingBase64
+	resp.Body = base64.StdEncoding.EncodeToString(raw)
+}
+
+func decodeText(raw []byte, declaredCharset string, contentTypeHeader string) (string, bool) {
+	if declaredCharset == "" || declaredCharset == "utf-8" || declaredCharset == "utf8" {
+		if !utf8.Valid(raw) {
+			return "", false
+		}
+		return string(raw), true
+	}
+
+	reader, err := charset.NewReader(bytes.NewReader(raw), contentTypeHeader)
+	if err != nil {
+		return "", false
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return "", false
+	}
+	return string(decoded), true
+}
+
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	if strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-javascript", "application/ecmascript", "application/x-www-form-urlencoded",
+		"application/yaml", "application/x-yaml", "application/graphql", "application/x-ndjson",
+		"image/svg+xml":
+		return true
+	}
+	return false
+}
+
+func buildPreview(raw []byte, mediaType string) *ResponsePreview {
+	switch {
+	case mediaType == "application/pdf":
+		return &ResponsePreview{Kind: "pdf", MimeType: mediaType}
+	case strings.HasPrefix(mediaType, "image/"):
+		preview := &ResponsePreview{Kind: "image", MimeType: mediaType}
+		if config, _, err := image.DecodeConfig(bytes.NewReader(raw)); err == nil {
+			preview.Width = config.Width
+			preview.Height = config.Height
+		}
+		return preview
+	}
+	return nil
+}
+
+// responseBodyCache keeps the raw bytes of the most recent responses so they can
+// be written to disk exactly as received.
+type responseBodyCache struct {
+	mu     sync.Mutex
+	bodies map[string][]byte
+	order  []string
+	limit  int
+}
+
+func newResponseBodyCache(limit int) *responseBodyCache {
+	return &responseBodyCache{
+		bodies: make(map[string][]byte),
+		limit:  limit,
+	}
+}
+
+func (c *responseBodyCache) Put(id string, raw []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.bodies[id]; !exists {
+		c.order = append(c.order, id)
+	}
+	c.bodies[id] = raw
+
+	for len(c.order) > c.limit {
+		delete(c.bodies, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+func (c *responseBodyCache) Get(id string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	raw, ok := c.bodies[id]
+	return raw, ok
+}
+
+// rawResponseBody recovers the bytes of a response that is no longer cached,
+// e.g. one loaded from history.
+func rawResponseBody(resp HttpResponse) ([]byte, error) {
+	if resp.BodyFile != "" {
+		raw, err := os.ReadFile(resp.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response file: %w", err)
+		}
+		return raw, nil
+	}
+	if resp.Truncated {
+		return nil, fmt.Errorf("response body was truncated and is no longer available")
+	}
+	if resp.BodyEncoding == BodyEncodingBase64 {
+		raw, err := base64.StdEncoding.DecodeString(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response body: %w", err)
+		}
+		return raw, nil
+	}
+	return []byte(resp.Body), nil
+}
+
+// historyResponse returns a copy of resp whose body is cut down to a preview,
+// so large downloads don't end up in history.json.
+func historyResponse(resp HttpResponse) HttpResponse {
+	if len(resp.Body) <= responsePreviewSize {
+		return resp
+	}
+
+	if resp.BodyEncoding == BodyEncodingBase64 {
+		resp.Body = resp.Body[:responsePreviewSize-responsePreviewSize%4]
+	} else {
+		resp.Body = string(trimPartialRune([]byte(resp.Body[:responsePreviewSize])))
+	}
+	resp.Truncated = true
+	return resp
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
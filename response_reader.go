@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxInMemoryBodySize = 10 << 20
+	responsePreviewSize = 64 << 10
+	progressInterval    = 100 * time.Millisecond
+)
+
+type DownloadProgress struct {
+	ID        string `json:"id"`
+	RequestId string `json:"requestId,omitempty"`
+	Received  int64  `json:"received"`
+	Total     int64  `json:"total"` // -1 when the server did not send Content-Length
+	Done      bool   `json:"done"`
+}
+
+type responseBodyResult struct {
+	data    []byte // the whole body, or only the preview when spilled to disk
+	file    string
+	size    int64
+	spilled bool
+}
+
+// progressReader reports the number of bytes read so far, throttled to progressInterval.
+type progressReader struct {
+	reader     io.Reader
+	total      int64
+	received   int64
+	lastReport time.Time
+	onProgress func(received, total int64)
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	p.received += int64(n)
+	if p.onProgress != nil && time.Since(p.lastReport) >= progressInterval {
+		p.lastReport = time.Now()
+		p.onProgress(p.received, p.total)
+	}
+	return n, err
+}
+
+// readResponseBody buffers up to maxInMemoryBodySize bytes and spills anything
+// larger to a file in spoolDir, keeping only a preview in memory.
+func readResponseBody(reader io.Reader, spoolDir string, id string) (*responseBodyResult, error) {
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, reader, maxInMemoryBodySize+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n <= maxInMemoryBodySize {
+		return &responseBodyResult{data: buf.Bytes(), size: n}, nil
+	}
+
+	if err := os.MkdirAll(spoolDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create response directory: %w", err)
+	}
+	path := filepath.Join(spoolDir, id+".body")
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create response file: %w", err)
+	}
+
+	preview := make([]byte, responsePreviewSize)
+	copy(preview, buf.Bytes())
+
+	written, err := io.Copy(file, io.MultiReader(&buf, reader))
+	// A failed close may have lost buffered data, so the file is only kept
+	// when both the copy and the close succeeded.
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write response file: %w", closeErr)
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	return &responseBodyResult{
+		data:    preview,
+		file:    path,
+		size:    written,
+		spilled: true,
+	}, nil
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence left at the end of a cut preview.
+func trimPartialRune(data []byte) []byte {
+	for i := 0; i < utf8.UTFMax && i < len(data); i++ {
+		r, size := utf8.DecodeLastRune(data[:len(data)-i])
+		if r != utf8.RuneError || size > 1 {
+			return data[:len(data)-i]
+		}
+	}
+	return data
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -9,11 +10,29 @@ import (
 )
 
 type ScriptRunner struct {
+	ctx context.Context
 	app *App
 }
 
-func NewScriptRunner(app *App) *ScriptRunner {
-	return &ScriptRunner{app: app}
+func NewScriptRunner(ctx context.Context, app *App) *ScriptRunner {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &ScriptRunner{ctx: ctx, app: app}
+}
+
+// interruptOnCancel stops the script when the request it belongs to is cancelled.
+// The returned function must be called once the script has finished.
+func (sr *ScriptRunner) interruptOnCancel(vm *goja.Runtime) func() {
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-sr.ctx.Done():
+			vm.Interrupt("请求已取消")
+		case <-finished:
+		}
+	}()
+	return func() { close(finished) }
 }
 
 type PMContext struct {
@@ -50,7 +69,9 @@ func (sr *ScriptRunner) RunPreRequestScript(req *HttpRequest) (*ScriptResult, er
 
 	sr.setupPMObject(vm, ctx, true)
 
+	stop := sr.interruptOnCancel(vm)
 	_, err := vm.RunString(req.Scripts.PreRequest)
+	stop()
 	if err != nil {
 		result.Error = fmt.Sprintf("Pre-request script error: %v", err)
 		return result, err
@@ -104,7 +125,9 @@ func (sr *ScriptRunner) RunPostRequestScript(req *HttpRequest, resp *HttpRespons
 
 	sr.setupPMObject(vm, ctx, false)
 
+	stop := sr.interruptOnCancel(vm)
 	_, err := vm.RunString(req.Scripts.PostRequest)
+	stop()
 	if err != nil {
 		result.Error = fmt.Sprintf("Post-request script error: %v", err)
 		return result, err
@@ -176,6 +199,32 @@ func (sr *ScriptRunner) setupPMObject(vm *goja.Runtime, ctx *PMContext, isPreReq
 	})
 	pm.Set("environment", environment)
 
+	tokens := vm.NewObject()
+	tokens.Set("get", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) == 0 {
+			return goja.Null()
+		}
+		idOrName := call.Arguments[0].String()
+		for _, token := range sr.app.tokenStorage.GetAllTokens() {
+			if token.ID == idOrName || token.Name == idOrName {
+				return vm.ToValue(token.Value)
+			}
+		}
+		return goja.Null()
+	})
+	tokens.Set("set", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 2 {
+			return goja.Undefined()
+		}
+		idOrName := call.Arguments[0].String()
+		value := call.Arguments[1].String()
+		if err := sr.app.tokenStorage.SetTokenValue(idOrName, value); err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return goja.Undefined()
+	})
+	pm.Set("tokens", tokens)
+
 	pm.Set("test", func(call goja.FunctionCall) goja.Value {
 		if len(call.Arguments) < 2 {
 			return goja.Undefined()
@@ -206,7 +255,8 @@ func (sr *ScriptRunner) setupPMObject(vm *goja.Runtime, ctx *PMContext, isPreReq
 
 		response.Set("code", ctx.response.Status)
 		response.Set("status", ctx.response.StatusText)
-		response.Set("headers", ctx.response.Headers)
+		response.Set("headers", headerMap(ctx.response.Headers))
+		response.Set("headerList", ctx.response.Headers)
 		response.Set("responseTime", ctx.response.Time)
 		response.Set("responseSize", ctx.response.Size)
 
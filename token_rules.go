@@ -0,0 +1,126 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	TokenInHeader = "header"
+	TokenInQuery  = "query"
+	TokenInCookie = "cookie"
+)
+
+// matches reports whether the token should be applied to a request of the given
+// project and environment sent to rawURL.
+func (t *Token) matches(projectId string, environmentId string, rawURL string) bool {
+	if !t.AutoApply || t.HeaderKey == "" {
+		return false
+	}
+	if t.ProjectId != "" && t.ProjectId != projectId {
+		return false
+	}
+	if t.EnvironmentId != "" && t.EnvironmentId != environmentId {
+		return false
+	}
+	if t.UrlPattern != "" && !matchURLPattern(t.UrlPattern, rawURL) {
+		return false
+	}
+	return true
+}
+
+// matchURLPattern matches rawURL against a pattern where * stands for any run
+// of characters. A pattern without a scheme also matches after the scheme.
+func matchURLPattern(pattern string, rawURL string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := strings.Join(parts, ".*")
+	if !strings.Contains(pattern, "://") {
+		expr = `(?:[a-zA-Z][a-zA-Z0-9+.-]*://)?` + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(rawURL)
+}
+
+// applyTokens adds every matching token to req unless the request already sets
+// the same header, query param or cookie itself. When several tokens target
+// the same name the most recently updated one wins.
+func applyTokens(req *HttpRequest, tokens []Token, environmentId string) {
+	applied := make(map[string]bool)
+	for _, token := range tokens {
+		if !token.matches(req.ProjectId, environmentId, req.URL) {
+			continue
+		}
+
+		location := strings.ToLower(token.In)
+		if location == "" {
+			location = TokenInHeader
+		}
+		key := location + ":" + strings.ToLower(token.HeaderKey)
+		if applied[key] {
+			continue
+		}
+		applied[key] = true
+
+		value := token.Prefix + token.Value
+		switch location {
+		case TokenInQuery:
+			if hasEnabledKey(req.Params, token.HeaderKey, false) {
+				continue
+			}
+			req.Params = append(append([]KeyValue(nil), req.Params...),
+				KeyValue{Key: token.HeaderKey, Value: value, Enabled: true})
+		case TokenInCookie:
+			cookie := token.HeaderKey + "=" + value
+			headers := append([]KeyValue(nil), req.Headers...)
+			found := false
+			for i, h := range headers {
+				if h.Enabled && strings.EqualFold(h.Key, "Cookie") {
+					if cookieNames(h.Value)[token.HeaderKey] {
+						found = true
+						break
+					}
+					headers[i].Value = h.Value + "; " + cookie
+					found = true
+					break
+				}
+			}
+			if !found {
+				headers = append(headers, KeyValue{Key: "Cookie", Value: cookie, Enabled: true})
+			}
+			req.Headers = headers
+		default:
+			if hasEnabledKey(req.Headers, token.HeaderKey, true) {
+				continue
+			}
+			req.Headers = append(append([]KeyValue(nil), req.Headers...),
+				KeyValue{Key: token.HeaderKey, Value: value, Enabled: true})
+		}
+	}
+}
+
+func hasEnabledKey(items []KeyValue, key string, caseInsensitive bool) bool {
+	for _, item := range items {
+		if !item.Enabled {
+			continue
+		}
+		if item.Key == key || caseInsensitive && strings.EqualFold(item.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func cookieNames(header string) map[string]bool {
+	names := make(map[string]bool)
+	for _, pair := range strings.Split(header, ";") {
+		name, _, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		names[name] = true
+	}
+	return names
+}
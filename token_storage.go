@@ -99,6 +99,22 @@ func (s *TokenStorage) GetToken(id string) *Token {
 	return nil
 }
 
+// SetTokenValue updates the value of the token with the given ID or name.
+func (s *TokenStorage) SetTokenValue(idOrName string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, token := range s.tokens {
+		if token.ID == idOrName || token.Name == idOrName {
+			s.tokens[i].Value = value
+			s.tokens[i].UpdatedAt = time.Now()
+			return s.save()
+		}
+	}
+
+	return fmt.Errorf("token not found: %s", idOrName)
+}
+
 func (s *TokenStorage) DeleteToken(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()